@@ -10,9 +10,13 @@ import (
 
 // Add computes the sum of two residues.
 func (z *Residue) Add(x *Residue) *Residue {
+	if constantTime {
+		return z.AddCT(x)
+	}
+
 	if z.m != x.m {
 		if z.m.m != x.m.m {
-			panic("Incompatible moduli")
+			panic(ErrIncompatibleModuli)
 		}
 	}
 
@@ -46,3 +50,13 @@ func (z *Residue) Add(x *Residue) *Residue {
 
 	return z
 }
+
+// Sum computes the sum of two residues x and y, and stores it in z.
+// Any of x, y and z may be the same residue.
+func (z *Residue) Sum(x, y *Residue) *Residue {
+	if z == y {
+		return z.Add(x)
+	}
+
+	return z.Copy(x).Add(y)
+}
@@ -0,0 +1,58 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package mod256
+
+import (
+	"golang.org/x/sys/cpu"
+)
+
+// useADX selects the assembly implementations, which need the MULX instruction
+// from BMI2 and the ADCX and ADOX instructions from ADX.
+var useADX = cpu.X86.HasBMI2 && cpu.X86.HasADX
+
+// mul512ADX computes the 512-bit product of two 256-bit values.
+//go:noescape
+func mul512ADX(z *[8]uint64, x, y *[4]uint64)
+
+// sqr512ADX computes the 512-bit square of a 256-bit value.
+//go:noescape
+func sqr512ADX(z *[8]uint64, x *[4]uint64)
+
+// reduce8ADX computes the same 256-bit residue of x as reduce8Generic.
+//go:noescape
+func reduce8ADX(z *[4]uint64, x *[8]uint64, m *[4]uint64, mu *[5]uint64)
+
+// mul512 computes the 512-bit product of two 256-bit values.
+func mul512(z *[8]uint64, x, y *[4]uint64) {
+	if useADX {
+		mul512ADX(z, x, y)
+		return
+	}
+
+	mul512Generic(z, x, y)
+}
+
+// sqr512 computes the 512-bit square of a 256-bit value.
+func sqr512(z *[8]uint64, x *[4]uint64) {
+	if useADX {
+		sqr512ADX(z, x)
+		return
+	}
+
+	sqr512Generic(z, x)
+}
+
+// reduce8Barrett computes a 256-bit residue of x modulo z.m and stores it in z
+func (z *Residue) reduce8Barrett(x [8]uint64) *Residue {
+	if useADX && !constantTime {
+		reduce8ADX(&z.r, &x, &z.m.m, &z.m.mu)
+		return z
+	}
+
+	return z.reduce8Generic(x)
+}
@@ -0,0 +1,364 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// The functions below need BMI2 (MULX) and ADX (ADCX, ADOX).
+// MULX leaves the flags untouched, so ADCX can accumulate one carry chain
+// in CF while ADOX accumulates another in OF.
+
+// func mul512ADX(z *[8]uint64, x, y *[4]uint64)
+TEXT ·mul512ADX(SB), NOSPLIT, $0-24
+	MOVQ z+0(FP), DI
+	MOVQ x+8(FP), SI
+	MOVQ y+16(FP), CX
+
+	// z[0..4] = x[0] * y
+
+	MOVQ  0(SI), DX
+	MULXQ  0(CX), R8, R9
+	MULXQ  8(CX), AX, R10
+	ADDQ  AX, R9
+	MULXQ 16(CX), AX, R11
+	ADCQ  AX, R10
+	MULXQ 24(CX), AX, R12
+	ADCQ  AX, R11
+	ADCQ  $0, R12
+
+	MOVQ R8, 0(DI)
+
+	// z[1..5] += x[1] * y
+
+	MOVQ  8(SI), DX
+	XORQ  R8, R8
+	MULXQ  0(CX), AX, BX
+	ADOXQ AX, R9
+	ADCXQ BX, R10
+	MULXQ  8(CX), AX, BX
+	ADOXQ AX, R10
+	ADCXQ BX, R11
+	MULXQ 16(CX), AX, BX
+	ADOXQ AX, R11
+	ADCXQ BX, R12
+	MULXQ 24(CX), AX, R13
+	ADOXQ AX, R12
+	ADCXQ R8, R13
+	ADOXQ R8, R13
+
+	MOVQ R9, 8(DI)
+
+	// z[2..6] += x[2] * y
+
+	MOVQ  16(SI), DX
+	XORQ  R8, R8
+	MULXQ  0(CX), AX, BX
+	ADOXQ AX, R10
+	ADCXQ BX, R11
+	MULXQ  8(CX), AX, BX
+	ADOXQ AX, R11
+	ADCXQ BX, R12
+	MULXQ 16(CX), AX, BX
+	ADOXQ AX, R12
+	ADCXQ BX, R13
+	MULXQ 24(CX), AX, R14
+	ADOXQ AX, R13
+	ADCXQ R8, R14
+	ADOXQ R8, R14
+
+	MOVQ R10, 16(DI)
+
+	// z[3..7] += x[3] * y
+
+	MOVQ  24(SI), DX
+	XORQ  R8, R8
+	MULXQ  0(CX), AX, BX
+	ADOXQ AX, R11
+	ADCXQ BX, R12
+	MULXQ  8(CX), AX, BX
+	ADOXQ AX, R12
+	ADCXQ BX, R13
+	MULXQ 16(CX), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, R14
+	MULXQ 24(CX), AX, R15
+	ADOXQ AX, R14
+	ADCXQ R8, R15
+	ADOXQ R8, R15
+
+	MOVQ R11, 24(DI)
+	MOVQ R12, 32(DI)
+	MOVQ R13, 40(DI)
+	MOVQ R14, 48(DI)
+	MOVQ R15, 56(DI)
+	RET
+
+// func sqr512ADX(z *[8]uint64, x *[4]uint64)
+TEXT ·sqr512ADX(SB), NOSPLIT, $0-16
+	MOVQ z+0(FP), DI
+	MOVQ x+8(FP), SI
+
+	// Off-diagonal products into z[1..6]
+
+	MOVQ  0(SI), DX
+	MULXQ  8(SI), R9, R10
+	MULXQ 16(SI), AX, R11
+	ADDQ  AX, R10
+	MULXQ 24(SI), AX, R12
+	ADCQ  AX, R11
+	ADCQ  $0, R12
+
+	MOVQ  8(SI), DX
+	XORQ  R8, R8
+	MULXQ 16(SI), AX, BX
+	ADOXQ AX, R11
+	ADCXQ BX, R12
+	MULXQ 24(SI), AX, R13
+	ADOXQ AX, R12
+	ADCXQ R8, R13
+	ADOXQ R8, R13
+
+	MOVQ  16(SI), DX
+	MULXQ 24(SI), AX, R14
+	ADDQ  AX, R13
+	ADCQ  $0, R14
+
+	// Double them
+
+	MOVQ $0, R15
+	ADDQ R9, R9
+	ADCQ R10, R10
+	ADCQ R11, R11
+	ADCQ R12, R12
+	ADCQ R13, R13
+	ADCQ R14, R14
+	ADCQ $0, R15
+
+	// Add the squares
+
+	MOVQ  0(SI), DX
+	MULXQ DX, R8, AX
+	ADDQ  AX, R9
+
+	MOVQ  8(SI), DX
+	MULXQ DX, AX, BX
+	ADCQ  AX, R10
+	ADCQ  BX, R11
+
+	MOVQ  16(SI), DX
+	MULXQ DX, AX, BX
+	ADCQ  AX, R12
+	ADCQ  BX, R13
+
+	MOVQ  24(SI), DX
+	MULXQ DX, AX, BX
+	ADCQ  AX, R14
+	ADCQ  BX, R15
+
+	MOVQ R8,   0(DI)
+	MOVQ R9,   8(DI)
+	MOVQ R10, 16(DI)
+	MOVQ R11, 24(DI)
+	MOVQ R12, 32(DI)
+	MOVQ R13, 40(DI)
+	MOVQ R14, 48(DI)
+	MOVQ R15, 56(DI)
+	RET
+
+// func reduce8ADX(z *[4]uint64, x *[8]uint64, m *[4]uint64, mu *[5]uint64)
+//
+// Same Barrett reduction as reduce8Generic, with the same truncated
+// products, so the results are identical.
+TEXT ·reduce8ADX(SB), NOSPLIT, $0-32
+	MOVQ x+8(FP), SI
+	MOVQ mu+24(FP), CX
+
+	// q = (x/2^192 * mu) / 2^320
+	// Words 4..9 of the product go in R13, R8..R12.
+	// Column 3 contributes only the high words of its products.
+
+	// mu[0] * x[6..7]
+
+	MOVQ  0(CX), DX
+	MULXQ 48(SI), AX, R13
+	MULXQ 56(SI), AX, R8
+	ADDQ  AX, R13
+	ADCQ  $0, R8
+
+	// mu[1] * x[5..7]
+
+	MOVQ  8(CX), DX
+	XORQ  R14, R14
+	MULXQ 40(SI), AX, BX
+	ADCXQ BX, R13
+	MULXQ 48(SI), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, R8
+	MULXQ 56(SI), AX, R9
+	ADOXQ AX, R8
+	ADCXQ R14, R9
+	ADOXQ R14, R9
+
+	// mu[2] * x[4..7]
+
+	MOVQ  16(CX), DX
+	XORQ  R14, R14
+	MULXQ 32(SI), AX, BX
+	ADCXQ BX, R13
+	MULXQ 40(SI), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, R8
+	MULXQ 48(SI), AX, BX
+	ADOXQ AX, R8
+	ADCXQ BX, R9
+	MULXQ 56(SI), AX, R10
+	ADOXQ AX, R9
+	ADCXQ R14, R10
+	ADOXQ R14, R10
+
+	// mu[3] * x[3..7]
+
+	MOVQ  24(CX), DX
+	XORQ  R14, R14
+	MULXQ 24(SI), AX, BX
+	ADCXQ BX, R13
+	MULXQ 32(SI), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, R8
+	MULXQ 40(SI), AX, BX
+	ADOXQ AX, R8
+	ADCXQ BX, R9
+	MULXQ 48(SI), AX, BX
+	ADOXQ AX, R9
+	ADCXQ BX, R10
+	MULXQ 56(SI), AX, R11
+	ADOXQ AX, R10
+	ADCXQ R14, R11
+	ADOXQ R14, R11
+
+	// mu[4] * x[3..7]
+
+	MOVQ  32(CX), DX
+	XORQ  R14, R14
+	MULXQ 24(SI), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, R8
+	MULXQ 32(SI), AX, BX
+	ADOXQ AX, R8
+	ADCXQ BX, R9
+	MULXQ 40(SI), AX, BX
+	ADOXQ AX, R9
+	ADCXQ BX, R10
+	MULXQ 48(SI), AX, BX
+	ADOXQ AX, R10
+	ADCXQ BX, R11
+	MULXQ 56(SI), AX, R12
+	ADOXQ AX, R11
+	ADCXQ R14, R12
+	ADOXQ R14, R12
+
+	// w = q * m mod 2^320, in R8, R13, SI, CX, R15
+	// q is in R8..R12
+
+	MOVQ m+16(FP), DI
+
+	MOVQ  R8, DX
+	MULXQ  0(DI), R8, R13
+	MULXQ  8(DI), AX, SI
+	ADDQ  AX, R13
+	MULXQ 16(DI), AX, CX
+	ADCQ  AX, SI
+	MULXQ 24(DI), AX, R15
+	ADCQ  AX, CX
+	ADCQ  $0, R15
+
+	MOVQ  R9, DX
+	XORQ  AX, AX
+	MULXQ  0(DI), AX, BX
+	ADOXQ AX, R13
+	ADCXQ BX, SI
+	MULXQ  8(DI), AX, BX
+	ADOXQ AX, SI
+	ADCXQ BX, CX
+	MULXQ 16(DI), AX, BX
+	ADOXQ AX, CX
+	ADCXQ BX, R15
+	MULXQ 24(DI), AX, BX
+	ADOXQ AX, R15
+
+	MOVQ  R10, DX
+	XORQ  AX, AX
+	MULXQ  0(DI), AX, BX
+	ADOXQ AX, SI
+	ADCXQ BX, CX
+	MULXQ  8(DI), AX, BX
+	ADOXQ AX, CX
+	ADCXQ BX, R15
+	MULXQ 16(DI), AX, BX
+	ADOXQ AX, R15
+
+	MOVQ  R11, DX
+	XORQ  AX, AX
+	MULXQ  0(DI), AX, BX
+	ADOXQ AX, CX
+	ADCXQ BX, R15
+	MULXQ  8(DI), AX, BX
+	ADOXQ AX, R15
+
+	MOVQ  R12, DX
+	MULXQ  0(DI), AX, BX
+	ADDQ  AX, R15
+
+	// r = x mod 2^320 - w, in R9..R12, AX
+
+	MOVQ x+8(FP), BX
+
+	MOVQ  0(BX), R9
+	SUBQ  R8, R9
+	MOVQ  8(BX), R10
+	SBBQ  R13, R10
+	MOVQ 16(BX), R11
+	SBBQ  SI, R11
+	MOVQ 24(BX), R12
+	SBBQ  CX, R12
+	MOVQ 32(BX), AX
+	SBBQ  R15, AX
+
+	TESTQ AX, AX
+	JZ    done
+
+	// r = r - m
+
+	SUBQ  0(DI), R9
+	SBBQ  8(DI), R10
+	SBBQ 16(DI), R11
+	SBBQ 24(DI), R12
+	SBBQ  $0, AX
+
+	// r = r - m if no borrow
+
+	MOVQ R9,  R8
+	MOVQ R10, R13
+	MOVQ R11, SI
+	MOVQ R12, CX
+	SUBQ  0(DI), R8
+	SBBQ  8(DI), R13
+	SBBQ 16(DI), SI
+	SBBQ 24(DI), CX
+	SBBQ  $0, AX
+
+	CMOVQCC R8,  R9
+	CMOVQCC R13, R10
+	CMOVQCC SI,  R11
+	CMOVQCC CX,  R12
+
+done:
+	MOVQ z+0(FP), DI
+	MOVQ R9,   0(DI)
+	MOVQ R10,  8(DI)
+	MOVQ R11, 16(DI)
+	MOVQ R12, 24(DI)
+	RET
@@ -0,0 +1,40 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build arm64 && !purego
+// +build arm64,!purego
+
+package mod256
+
+// mul512Arm64 computes the 512-bit product of two 256-bit values.
+//go:noescape
+func mul512Arm64(z *[8]uint64, x, y *[4]uint64)
+
+// sqr512Arm64 computes the 512-bit square of a 256-bit value.
+//go:noescape
+func sqr512Arm64(z *[8]uint64, x *[4]uint64)
+
+// reduce8Arm64 computes the same 256-bit residue of x as reduce8Generic.
+//go:noescape
+func reduce8Arm64(z *[4]uint64, x *[8]uint64, m *[4]uint64, mu *[5]uint64)
+
+// mul512 computes the 512-bit product of two 256-bit values.
+func mul512(z *[8]uint64, x, y *[4]uint64) {
+	mul512Arm64(z, x, y)
+}
+
+// sqr512 computes the 512-bit square of a 256-bit value.
+func sqr512(z *[8]uint64, x *[4]uint64) {
+	sqr512Arm64(z, x)
+}
+
+// reduce8Barrett computes a 256-bit residue of x modulo z.m and stores it in z
+func (z *Residue) reduce8Barrett(x [8]uint64) *Residue {
+	if !constantTime {
+		reduce8Arm64(&z.r, &x, &z.m.m, &z.m.mu)
+		return z
+	}
+
+	return z.reduce8Generic(x)
+}
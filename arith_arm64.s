@@ -0,0 +1,378 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build arm64 && !purego
+// +build arm64,!purego
+
+#include "textflag.h"
+
+// Each row of products is added in two passes, first the low words
+// (MUL) and then the high words (UMULH), as ARM64 has a single carry flag.
+
+// func mul512Arm64(z *[8]uint64, x, y *[4]uint64)
+TEXT ·mul512Arm64(SB), NOSPLIT, $0-24
+	MOVD z+0(FP), R0
+	MOVD x+8(FP), R1
+	MOVD y+16(FP), R2
+
+	LDP  0(R1), (R3, R4)
+	LDP 16(R1), (R5, R6)
+	LDP  0(R2), (R7, R8)
+	LDP 16(R2), (R9, R10)
+
+	// z[0..4] = x[0] * y
+
+	MUL   R7,  R3, R11
+	MUL   R8,  R3, R12
+	MUL   R9,  R3, R13
+	MUL   R10, R3, R14
+	UMULH R7,  R3, R20
+	UMULH R8,  R3, R21
+	UMULH R9,  R3, R22
+	UMULH R10, R3, R23
+	ADDS  R20, R12
+	ADCS  R21, R13
+	ADCS  R22, R14
+	ADC   ZR,  R23, R15
+
+	// z[1..5] += x[1] * y
+
+	MUL   R7,  R4, R20
+	MUL   R8,  R4, R21
+	MUL   R9,  R4, R22
+	MUL   R10, R4, R23
+	ADDS  R20, R12
+	ADCS  R21, R13
+	ADCS  R22, R14
+	ADCS  R23, R15
+	ADC   ZR,  ZR, R16
+	UMULH R7,  R4, R20
+	UMULH R8,  R4, R21
+	UMULH R9,  R4, R22
+	UMULH R10, R4, R23
+	ADDS  R20, R13
+	ADCS  R21, R14
+	ADCS  R22, R15
+	ADC   R23, R16
+
+	// z[2..6] += x[2] * y
+
+	MUL   R7,  R5, R20
+	MUL   R8,  R5, R21
+	MUL   R9,  R5, R22
+	MUL   R10, R5, R23
+	ADDS  R20, R13
+	ADCS  R21, R14
+	ADCS  R22, R15
+	ADCS  R23, R16
+	ADC   ZR,  ZR, R17
+	UMULH R7,  R5, R20
+	UMULH R8,  R5, R21
+	UMULH R9,  R5, R22
+	UMULH R10, R5, R23
+	ADDS  R20, R14
+	ADCS  R21, R15
+	ADCS  R22, R16
+	ADC   R23, R17
+
+	// z[3..7] += x[3] * y
+
+	MUL   R7,  R6, R20
+	MUL   R8,  R6, R21
+	MUL   R9,  R6, R22
+	MUL   R10, R6, R23
+	ADDS  R20, R14
+	ADCS  R21, R15
+	ADCS  R22, R16
+	ADCS  R23, R17
+	ADC   ZR,  ZR, R19
+	UMULH R7,  R6, R20
+	UMULH R8,  R6, R21
+	UMULH R9,  R6, R22
+	UMULH R10, R6, R23
+	ADDS  R20, R15
+	ADCS  R21, R16
+	ADCS  R22, R17
+	ADC   R23, R19
+
+	STP (R11, R12),  0(R0)
+	STP (R13, R14), 16(R0)
+	STP (R15, R16), 32(R0)
+	STP (R17, R19), 48(R0)
+	RET
+
+// func sqr512Arm64(z *[8]uint64, x *[4]uint64)
+TEXT ·sqr512Arm64(SB), NOSPLIT, $0-16
+	MOVD z+0(FP), R0
+	MOVD x+8(FP), R1
+
+	LDP  0(R1), (R3, R4)
+	LDP 16(R1), (R5, R6)
+
+	// Off-diagonal products into z[1..6]
+
+	MUL   R4, R3, R8
+	MUL   R5, R3, R9
+	MUL   R6, R3, R10
+	UMULH R4, R3, R15
+	UMULH R5, R3, R16
+	UMULH R6, R3, R11
+	ADDS  R15, R9
+	ADCS  R16, R10
+	ADC   ZR,  R11
+
+	MUL   R5, R4, R15
+	UMULH R5, R4, R16
+	MUL   R6, R4, R17
+	UMULH R6, R4, R12
+	ADDS  R15, R10
+	ADCS  R16, R11
+	ADC   ZR,  R12
+	ADDS  R17, R11
+	ADC   ZR,  R12
+
+	MUL   R6, R5, R15
+	UMULH R6, R5, R13
+	ADDS  R15, R12
+	ADC   ZR,  R13
+
+	// Double them
+
+	ADDS R8,  R8
+	ADCS R9,  R9
+	ADCS R10, R10
+	ADCS R11, R11
+	ADCS R12, R12
+	ADCS R13, R13
+	ADC  ZR,  ZR, R14
+
+	// Add the squares
+
+	MUL   R3, R3, R7
+	UMULH R3, R3, R15
+	MUL   R4, R4, R16
+	UMULH R4, R4, R17
+	MUL   R5, R5, R19
+	UMULH R5, R5, R20
+	MUL   R6, R6, R21
+	UMULH R6, R6, R22
+	ADDS  R15, R8
+	ADCS  R16, R9
+	ADCS  R17, R10
+	ADCS  R19, R11
+	ADCS  R20, R12
+	ADCS  R21, R13
+	ADC   R22, R14
+
+	STP (R7,  R8),   0(R0)
+	STP (R9,  R10), 16(R0)
+	STP (R11, R12), 32(R0)
+	STP (R13, R14), 48(R0)
+	RET
+
+// func reduce8Arm64(z *[4]uint64, x *[8]uint64, m *[4]uint64, mu *[5]uint64)
+//
+// Same Barrett reduction as reduce8Generic, with the same truncated
+// products, so the results are identical.
+TEXT ·reduce8Arm64(SB), NOSPLIT, $0-32
+	MOVD x+8(FP), R0
+	MOVD m+16(FP), R2
+	MOVD mu+24(FP), R1
+
+	// q1 = x/2^192 in R4..R8
+
+	LDP  24(R0), (R4, R5)
+	LDP  40(R0), (R6, R7)
+	MOVD 56(R0), R8
+
+	// q = (q1 * mu) / 2^320
+	// Words 4..9 of the product go in R10..R15.
+	// Column 3 contributes only the high words of its products.
+
+	// mu[0] * q1[3..4]
+
+	MOVD  0(R1), R9
+	UMULH R9, R7, R10
+	MUL   R9, R8, R16
+	UMULH R9, R8, R11
+	ADDS  R16, R10
+	ADC   ZR,  R11
+
+	// mu[1] * q1[2..4]
+
+	MOVD  8(R1), R9
+	MUL   R9, R7, R16
+	MUL   R9, R8, R17
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADC   ZR,  ZR, R12
+	UMULH R9, R6, R16
+	UMULH R9, R7, R17
+	UMULH R9, R8, R19
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADC   R19, R12
+
+	// mu[2] * q1[1..4]
+
+	MOVD  16(R1), R9
+	MUL   R9, R6, R16
+	MUL   R9, R7, R17
+	MUL   R9, R8, R19
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADCS  R19, R12
+	ADC   ZR,  ZR, R13
+	UMULH R9, R5, R16
+	UMULH R9, R6, R17
+	UMULH R9, R7, R19
+	UMULH R9, R8, R20
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADCS  R19, R12
+	ADC   R20, R13
+
+	// mu[3] * q1[0..4]
+
+	MOVD  24(R1), R9
+	MUL   R9, R5, R16
+	MUL   R9, R6, R17
+	MUL   R9, R7, R19
+	MUL   R9, R8, R20
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADCS  R19, R12
+	ADCS  R20, R13
+	ADC   ZR,  ZR, R14
+	UMULH R9, R4, R16
+	UMULH R9, R5, R17
+	UMULH R9, R6, R19
+	UMULH R9, R7, R20
+	UMULH R9, R8, R21
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADCS  R19, R12
+	ADCS  R20, R13
+	ADC   R21, R14
+
+	// mu[4] * q1[0..4]
+
+	MOVD  32(R1), R9
+	MUL   R9, R4, R16
+	MUL   R9, R5, R17
+	MUL   R9, R6, R19
+	MUL   R9, R7, R20
+	MUL   R9, R8, R21
+	ADDS  R16, R10
+	ADCS  R17, R11
+	ADCS  R19, R12
+	ADCS  R20, R13
+	ADCS  R21, R14
+	ADC   ZR,  ZR, R15
+	UMULH R9, R4, R16
+	UMULH R9, R5, R17
+	UMULH R9, R6, R19
+	UMULH R9, R7, R20
+	UMULH R9, R8, R21
+	ADDS  R16, R11
+	ADCS  R17, R12
+	ADCS  R19, R13
+	ADCS  R20, R14
+	ADC   R21, R15
+
+	// w = q * m mod 2^320, in R8, R22..R25
+	// q is in R11..R15
+
+	LDP 0(R2), (R4, R5)
+	LDP 16(R2), (R6, R7)
+
+	MUL   R4, R11, R8
+	MUL   R5, R11, R22
+	MUL   R6, R11, R23
+	MUL   R7, R11, R24
+	UMULH R4, R11, R16
+	UMULH R5, R11, R17
+	UMULH R6, R11, R19
+	UMULH R7, R11, R20
+	ADDS  R16, R22
+	ADCS  R17, R23
+	ADCS  R19, R24
+	ADC   ZR,  R20, R25
+
+	MUL   R4, R12, R16
+	MUL   R5, R12, R17
+	MUL   R6, R12, R19
+	MUL   R7, R12, R20
+	ADDS  R16, R22
+	ADCS  R17, R23
+	ADCS  R19, R24
+	ADC   R20, R25
+	UMULH R4, R12, R16
+	UMULH R5, R12, R17
+	UMULH R6, R12, R19
+	ADDS  R16, R23
+	ADCS  R17, R24
+	ADC   R19, R25
+
+	MUL   R4, R13, R16
+	MUL   R5, R13, R17
+	MUL   R6, R13, R19
+	ADDS  R16, R23
+	ADCS  R17, R24
+	ADC   R19, R25
+	UMULH R4, R13, R16
+	UMULH R5, R13, R17
+	ADDS  R16, R24
+	ADC   R17, R25
+
+	MUL   R4, R14, R16
+	MUL   R5, R14, R17
+	ADDS  R16, R24
+	ADC   R17, R25
+	UMULH R4, R14, R16
+	ADD   R16, R25
+
+	MUL   R4, R15, R16
+	ADD   R16, R25
+
+	// r = x mod 2^320 - w, in R9..R13
+
+	LDP  0(R0), (R9, R10)
+	LDP 16(R0), (R11, R12)
+	MOVD 32(R0), R13
+
+	SUBS R8,  R9
+	SBCS R22, R10
+	SBCS R23, R11
+	SBCS R24, R12
+	SBC  R25, R13
+
+	CBZ R13, done
+
+	// r = r - m
+
+	SUBS R4, R9
+	SBCS R5, R10
+	SBCS R6, R11
+	SBCS R7, R12
+	SBC  ZR, R13
+
+	// r = r - m if no borrow
+
+	SUBS R4, R9,  R16
+	SBCS R5, R10, R17
+	SBCS R6, R11, R19
+	SBCS R7, R12, R20
+	SBCS ZR, R13, R21
+
+	CSEL CS, R16, R9,  R9
+	CSEL CS, R17, R10, R10
+	CSEL CS, R19, R11, R11
+	CSEL CS, R20, R12, R12
+
+done:
+	MOVD z+0(FP), R3
+	STP (R9,  R10),  0(R3)
+	STP (R11, R12), 16(R3)
+	RET
@@ -0,0 +1,23 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !amd64 && !arm64 || purego
+// +build !amd64,!arm64 purego
+
+package mod256
+
+// mul512 computes the 512-bit product of two 256-bit values.
+func mul512(z *[8]uint64, x, y *[4]uint64) {
+	mul512Generic(z, x, y)
+}
+
+// sqr512 computes the 512-bit square of a 256-bit value.
+func sqr512(z *[8]uint64, x *[4]uint64) {
+	sqr512Generic(z, x)
+}
+
+// reduce8Barrett computes a 256-bit residue of x modulo z.m and stores it in z
+func (z *Residue) reduce8Barrett(x [8]uint64) *Residue {
+	return z.reduce8Generic(x)
+}
@@ -0,0 +1,105 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+// BatchInv computes the inverses of the residues in src, and stores them in dst.
+// It uses Montgomery's trick, costing a single inversion and about 3n multiplications for n invertible residues.
+//
+// Residues without an inverse are set to 0 in dst, like Inv does, and marked false in ok.
+// Zero residues are skipped, and other non-invertible residues (only possible with composite moduli)
+// are located by splitting the batch, so one of them does not prevent inversion of the others.
+// Returns true if all residues were invertible.
+//
+// The slices dst and src must have the same length, and must not overlap.
+// The slice ok must have the same length, or be nil.
+// All residues in src must have the same modulus.
+func BatchInv(dst, src []Residue, ok []bool) bool {
+	if len(dst) != len(src) || (ok != nil && len(ok) != len(src)) {
+		panic("Length mismatch")
+	}
+
+	if len(src) == 0 {
+		return true
+	}
+
+	m := src[0].m
+
+	for i := range src {
+		if src[i].m != m {
+			if src[i].m.m != m.m {
+				panic(ErrIncompatibleModuli)
+			}
+		}
+	}
+
+	return batchInv(dst, src, ok)
+}
+
+func batchInv(dst, src []Residue, ok []bool) bool {
+	var acc Residue
+
+	n := len(src)
+
+	// Prefix products: dst[i] = src[0] * ... * src[i-1], skipping zeros
+
+	acc.SetOne(src[0].m)
+
+	for i := range src {
+		dst[i].Copy(&acc)
+
+		if !src[i].IsZero() {
+			acc.Mul(&src[i])
+		}
+	}
+
+	if acc.Inv() {
+		// acc = 1/(src[0] * ... * src[i])
+
+		all := true
+
+		for i := n-1; i >= 0; i-- {
+			zero := src[i].IsZero()
+
+			if zero {
+				dst[i].SetZero(src[i].m)
+				all = false
+			} else {
+				dst[i].Mul(&acc)
+				acc.Mul(&src[i])
+			}
+
+			if ok != nil {
+				ok[i] = !zero
+			}
+		}
+
+		return all
+	}
+
+	if n == 1 {
+		dst[0].SetZero(src[0].m)
+
+		if ok != nil {
+			ok[0] = false
+		}
+
+		return false
+	}
+
+	// Some non-zero residue lacks an inverse: split and retry
+
+	h := n / 2
+
+	if ok == nil {
+		l := batchInv(dst[:h], src[:h], nil)
+		r := batchInv(dst[h:], src[h:], nil)
+		return l && r
+	}
+
+	l := batchInv(dst[:h], src[:h], ok[:h])
+	r := batchInv(dst[h:], src[h:], ok[h:])
+
+	return l && r
+}
@@ -0,0 +1,94 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// NewModulusFromBig creates a new modulus object from a big.Int.
+func NewModulusFromBig(m *big.Int) (z *Modulus, err error) {
+
+	if m.Sign() < 0 {
+		return nil, ErrModulusTooSmall
+	}
+
+	if m.BitLen() > 256 {
+		return nil, ErrModulusTooLarge
+	}
+
+	w := m.Bits()
+
+	return NewModulusFromUint64([4]uint64{ limb(w, 0), limb(w, 1), limb(w, 2), limb(w, 3) })
+}
+
+// ToBig returns a big.Int with the modulus.
+func (z *Modulus) ToBig() *big.Int {
+	return toBig(new(big.Int), z.m)
+}
+
+// FromBig sets the residue value from a big.Int.
+// Any integer is accepted, including negative values and values larger than the modulus.
+func (z *Residue) FromBig(m *Modulus, x *big.Int) *Residue {
+	w := x.Bits()
+
+	n := (len(w) * bits.UintSize + 63) / 64 // number of 64-bit limbs
+	k := (n + 3) / 4                          // number of 256-bit chunks
+
+	if k == 0 {
+		return z.FromUint64(m, [4]uint64{ 0, 0, 0, 0 })
+	}
+
+	// Most significant chunk first, then one chunk at a time
+
+	k--
+	z.FromUint64(m, [4]uint64{ limb(w, 4*k), limb(w, 4*k+1), limb(w, 4*k+2), limb(w, 4*k+3) })
+
+	for k > 0 {
+		k--
+		z.append256([4]uint64{ limb(w, 4*k), limb(w, 4*k+1), limb(w, 4*k+2), limb(w, 4*k+3) })
+	}
+
+	if x.Sign() < 0 {
+		z.Neg()
+	}
+
+	return z
+}
+
+// ToBig sets x to the canonical representative of the residue class and returns x.
+// No allocation takes place if x already has room for 256 bits.
+func (z *Residue) ToBig(x *big.Int) *big.Int {
+	return toBig(x, z.ToUint64())
+}
+
+// toBig sets z to the 256-bit value in a little-endian array of uint64.
+func toBig(z *big.Int, x [4]uint64) *big.Int {
+	b := toBytes32BE(x)
+
+	return z.SetBytes(b[:])
+}
+
+// limb returns 64-bit limb i of a little-endian slice of big.Word, independently of word size.
+func limb(w []big.Word, i int) uint64 {
+	if bits.UintSize == 64 {
+		if i < len(w) {
+			return uint64(w[i])
+		}
+		return 0
+	}
+
+	var l uint64
+
+	if 2*i+1 < len(w) {
+		l = uint64(w[2*i+1]) << 32
+	}
+	if 2*i < len(w) {
+		l |= uint64(w[2*i])
+	}
+
+	return l
+}
@@ -0,0 +1,228 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"encoding/binary"
+	. "math/bits"
+)
+
+// NewModulusFromBytes32BE creates a new modulus object from a 32-byte big-endian array.
+func NewModulusFromBytes32BE(b [32]byte) (z *Modulus, err error) {
+	return NewModulusFromUint64(fromBytes32BE(&b))
+}
+
+// NewModulusFromBytes32LE creates a new modulus object from a 32-byte little-endian array.
+func NewModulusFromBytes32LE(b [32]byte) (z *Modulus, err error) {
+	return NewModulusFromUint64(fromBytes32LE(&b))
+}
+
+// NewModulusFromBytes creates a new modulus object from a big-endian byte slice of any length.
+// Leading zero bytes are ignored.
+func NewModulusFromBytes(b []byte) (z *Modulus, err error) {
+	for len(b) > 32 {
+		if b[0] != 0 {
+			return nil, ErrModulusTooLarge
+		}
+		b = b[1:]
+	}
+
+	return NewModulusFromUint64(chunkBE(b, 0))
+}
+
+// Bytes32BE returns the modulus as a 32-byte big-endian array.
+func (z *Modulus) Bytes32BE() [32]byte {
+	return toBytes32BE(z.m)
+}
+
+// Bytes32LE returns the modulus as a 32-byte little-endian array.
+func (z *Modulus) Bytes32LE() [32]byte {
+	return toBytes32LE(z.m)
+}
+
+// FillBytes sets buf to the modulus as a zero-extended big-endian byte slice, and returns buf.
+// It panics if the modulus does not fit in buf.
+func (z *Modulus) FillBytes(buf []byte) []byte {
+	return fillBytes(buf, z.m)
+}
+
+// SetBytes32BE sets the residue value from a 32-byte big-endian array.
+func (z *Residue) SetBytes32BE(m *Modulus, b [32]byte) *Residue {
+	return z.FromUint64(m, fromBytes32BE(&b))
+}
+
+// SetBytes32LE sets the residue value from a 32-byte little-endian array.
+func (z *Residue) SetBytes32LE(m *Modulus, b [32]byte) *Residue {
+	return z.FromUint64(m, fromBytes32LE(&b))
+}
+
+// SetCanonicalBytes32BE sets the residue value from a 32-byte big-endian array.
+// It fails, leaving z unchanged, unless the value is the canonical representative of its residue class.
+func (z *Residue) SetCanonicalBytes32BE(m *Modulus, b [32]byte) (*Residue, error) {
+	return z.setCanonical(m, fromBytes32BE(&b))
+}
+
+// SetCanonicalBytes32LE sets the residue value from a 32-byte little-endian array.
+// It fails, leaving z unchanged, unless the value is the canonical representative of its residue class.
+func (z *Residue) SetCanonicalBytes32LE(m *Modulus, b [32]byte) (*Residue, error) {
+	return z.setCanonical(m, fromBytes32LE(&b))
+}
+
+// SetBytes sets the residue value from a big-endian byte slice of any length.
+func (z *Residue) SetBytes(m *Modulus, b []byte) *Residue {
+	k := (len(b) + 31) / 32 // number of 256-bit chunks
+
+	if k == 0 {
+		return z.FromUint64(m, [4]uint64{ 0, 0, 0, 0 })
+	}
+
+	// Most significant chunk first, then one chunk at a time
+
+	k--
+	z.FromUint64(m, chunkBE(b, k))
+
+	for k > 0 {
+		k--
+		z.append256(chunkBE(b, k))
+	}
+
+	return z
+}
+
+// SetBytesLE sets the residue value from a little-endian byte slice of any length.
+func (z *Residue) SetBytesLE(m *Modulus, b []byte) *Residue {
+	k := (len(b) + 31) / 32 // number of 256-bit chunks
+
+	if k == 0 {
+		return z.FromUint64(m, [4]uint64{ 0, 0, 0, 0 })
+	}
+
+	// Most significant chunk first, then one chunk at a time
+
+	k--
+	z.FromUint64(m, chunkLE(b, k))
+
+	for k > 0 {
+		k--
+		z.append256(chunkLE(b, k))
+	}
+
+	return z
+}
+
+// Bytes32BE returns the canonical representative of the residue class as a 32-byte big-endian array.
+func (z *Residue) Bytes32BE() [32]byte {
+	return toBytes32BE(z.ToUint64())
+}
+
+// Bytes32LE returns the canonical representative of the residue class as a 32-byte little-endian array.
+func (z *Residue) Bytes32LE() [32]byte {
+	return toBytes32LE(z.ToUint64())
+}
+
+// FillBytes sets buf to the canonical representative of the residue class
+// as a zero-extended big-endian byte slice, and returns buf.
+// It panics if the value does not fit in buf.
+func (z *Residue) FillBytes(buf []byte) []byte {
+	return fillBytes(buf, z.ToUint64())
+}
+
+// setCanonical sets the residue value if x < m, and fails otherwise.
+func (z *Residue) setCanonical(m *Modulus, x [4]uint64) (*Residue, error) {
+	var b uint64
+
+	_, b = Sub64(x[0], m.m[0], 0)
+	_, b = Sub64(x[1], m.m[1], b)
+	_, b = Sub64(x[2], m.m[2], b)
+	_, b = Sub64(x[3], m.m[3], b)
+
+	if b == 0 { // x >= m
+		return z, ErrNonCanonical
+	}
+
+	return z.FromUint64(m, x), nil
+}
+
+// chunkBE returns 256-bit chunk k, counting from the least significant end, of a big-endian byte slice.
+func chunkBE(b []byte, k int) [4]uint64 {
+	var t [32]byte
+
+	hi := len(b) - 32*k
+	lo := hi - 32
+
+	if lo < 0 {
+		lo = 0
+	}
+
+	copy(t[32-(hi-lo):], b[lo:hi])
+
+	return fromBytes32BE(&t)
+}
+
+// chunkLE returns 256-bit chunk k, counting from the least significant end, of a little-endian byte slice.
+func chunkLE(b []byte, k int) [4]uint64 {
+	var t [32]byte
+
+	copy(t[:], b[32*k:])
+
+	return fromBytes32LE(&t)
+}
+
+func fromBytes32BE(b *[32]byte) [4]uint64 {
+	return [4]uint64{
+		binary.BigEndian.Uint64(b[24:]),
+		binary.BigEndian.Uint64(b[16:]),
+		binary.BigEndian.Uint64(b[ 8:]),
+		binary.BigEndian.Uint64(b[ 0:]),
+	}
+}
+
+func fromBytes32LE(b *[32]byte) [4]uint64 {
+	return [4]uint64{
+		binary.LittleEndian.Uint64(b[ 0:]),
+		binary.LittleEndian.Uint64(b[ 8:]),
+		binary.LittleEndian.Uint64(b[16:]),
+		binary.LittleEndian.Uint64(b[24:]),
+	}
+}
+
+func toBytes32BE(x [4]uint64) (b [32]byte) {
+	binary.BigEndian.PutUint64(b[24:], x[0])
+	binary.BigEndian.PutUint64(b[16:], x[1])
+	binary.BigEndian.PutUint64(b[ 8:], x[2])
+	binary.BigEndian.PutUint64(b[ 0:], x[3])
+	return b
+}
+
+func toBytes32LE(x [4]uint64) (b [32]byte) {
+	binary.LittleEndian.PutUint64(b[ 0:], x[0])
+	binary.LittleEndian.PutUint64(b[ 8:], x[1])
+	binary.LittleEndian.PutUint64(b[16:], x[2])
+	binary.LittleEndian.PutUint64(b[24:], x[3])
+	return b
+}
+
+// fillBytes stores x in buf as a zero-extended big-endian value.
+func fillBytes(buf []byte, x [4]uint64) []byte {
+	b := toBytes32BE(x)
+	n := len(buf)
+
+	if n < 32 {
+		for _, v := range b[:32-n] {
+			if v != 0 {
+				panic("Buffer too small")
+			}
+		}
+		copy(buf, b[32-n:])
+		return buf
+	}
+
+	for i := range buf[:n-32] {
+		buf[i] = 0
+	}
+	copy(buf[n-32:], b[:])
+
+	return buf
+}
@@ -6,6 +6,10 @@ package mod256
 
 // Equal compares one residue to another, returns true when equal.
 func (x *Residue) Equal(y *Residue) bool {
+	if constantTime {
+		return x.EqualCT(y)
+	}
+
 	m := x.m.m[0] ^ y.m.m[0]
 	m |= x.m.m[1] ^ y.m.m[1]
 	m |= x.m.m[2] ^ y.m.m[2]
@@ -28,6 +32,10 @@ func (x *Residue) Equal(y *Residue) bool {
 
 // NotEqual compares one residue to another, returns true when different.
 func (x *Residue) NotEqual(y *Residue) bool {
+	if constantTime {
+		return !x.EqualCT(y)
+	}
+
 	m := x.m.m[0] ^ y.m.m[0]
 	m |= x.m.m[1] ^ y.m.m[1]
 	m |= x.m.m[2] ^ y.m.m[2]
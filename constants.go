@@ -0,0 +1,200 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"encoding/binary"
+	"errors"
+	. "math/bits"
+)
+
+// ModulusConstants contains a modulus together with the values derived from it by NewModulusFromUint64.
+// It allows a modulus object to be recreated without recomputing the reciprocal.
+//
+// Formatting with %#v gives a Go composite literal, which can be used to embed
+// the constants in generated source code.
+type ModulusConstants struct {
+	M    [4]uint64 // modulus
+	Mu   [5]uint64 // reciprocal
+	Mmu0 [4]uint64 // m*(mu/2^256 + 0)
+	Mmu1 [4]uint64 // m*(mu/2^256 + 1) % 2^256
+}
+
+// Constants returns the modulus and its derived values.
+func (z *Modulus) Constants() ModulusConstants {
+	return ModulusConstants{ M: z.m, Mu: z.mu, Mmu0: z.mmu0, Mmu1: z.mmu1 }
+}
+
+// NewModulusFromConstants creates a new modulus object from a modulus and its derived values.
+// With validate set, the derived values are checked to be correct for the modulus,
+// as done by Validate. Otherwise they are trusted, and only the range of the modulus is checked.
+func NewModulusFromConstants(c ModulusConstants, validate bool) (z *Modulus, err error) {
+
+	if c.M[3] == 0 {
+		return nil, ErrModulusTooSmall
+	}
+
+	z = &Modulus{ m: c.M, mu: c.Mu, mmu0: c.Mmu0, mmu1: c.Mmu1 }
+
+	if validate {
+		if err = z.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	z.initReduction()
+
+	return z, nil
+}
+
+// Validate checks the modulus and the values derived from it, and returns an error if they are incorrect.
+func (z *Modulus) Validate() error {
+	var c, b uint64
+
+	m := z.m
+
+	if m[3] == 0 {
+		return ErrModulusTooSmall
+	}
+
+	// mu * m < 2^512 <= mu * m + m
+
+	var p [10]uint64
+
+	for i:=0; i<5; i++ {
+		var h, l uint64
+
+		c = 0
+
+		for j:=0; j<4; j++ {
+			h, l = Mul64(z.mu[i], m[j])
+			l, b = Add64(l, c, 0)
+			h, _ = Add64(h, 0, b)
+			p[i+j], b = Add64(p[i+j], l, 0)
+			c, _ = Add64(h, 0, b)
+		}
+
+		p[i+4] = c
+	}
+
+	if p[8] | p[9] != 0 {
+		return errors.New("Invalid reciprocal")
+	}
+
+	_, c = Add64(p[0], m[0], 0)
+	_, c = Add64(p[1], m[1], c)
+	_, c = Add64(p[2], m[2], c)
+	_, c = Add64(p[3], m[3], c)
+
+	for i:=4; i<8; i++ {
+		_, c = Add64(p[i], 0, c)
+	}
+
+	if c == 0 {
+		return errors.New("Invalid reciprocal")
+	}
+
+	// mmu0 = m * mu[4] < 2^256
+
+	var q [5]uint64
+
+	c = 0
+
+	for j:=0; j<4; j++ {
+		h, l := Mul64(z.mu[4], m[j])
+		q[j], b = Add64(l, c, 0)
+		c, _ = Add64(h, 0, b)
+	}
+
+	q[4] = c
+
+	if q[4] != 0 || [4]uint64{ q[0], q[1], q[2], q[3] } != z.mmu0 {
+		return errors.New("Invalid mmu0")
+	}
+
+	// mmu1 = mmu0 + m >= 2^256
+
+	var t [4]uint64
+
+	t[0], c = Add64(z.mmu0[0], m[0], 0)
+	t[1], c = Add64(z.mmu0[1], m[1], c)
+	t[2], c = Add64(z.mmu0[2], m[2], c)
+	t[3], c = Add64(z.mmu0[3], m[3], c)
+
+	if c != 1 || t != z.mmu1 {
+		return errors.New("Invalid mmu1")
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The encoding consists of the little-endian 64-bit words of the modulus, mu, mmu0 and mmu1, in that order.
+func (z *Modulus) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 17*8)
+
+	for _, w := range z.m {
+		b = appendUint64LE(b, w)
+	}
+	for _, w := range z.mu {
+		b = appendUint64LE(b, w)
+	}
+	for _, w := range z.mmu0 {
+		b = appendUint64LE(b, w)
+	}
+	for _, w := range z.mmu1 {
+		b = appendUint64LE(b, w)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The decoded values are always validated.
+// The receiver must be a zero Modulus, as one in use may be shared with other goroutines.
+func (z *Modulus) UnmarshalBinary(data []byte) error {
+	var c ModulusConstants
+
+	if z.m != [4]uint64{} {
+		return errors.New("Modulus already initialized")
+	}
+
+	if len(data) != 17*8 {
+		return errors.New("Invalid modulus encoding")
+	}
+
+	for i := range c.M {
+		c.M[i], data = binary.LittleEndian.Uint64(data), data[8:]
+	}
+	for i := range c.Mu {
+		c.Mu[i], data = binary.LittleEndian.Uint64(data), data[8:]
+	}
+	for i := range c.Mmu0 {
+		c.Mmu0[i], data = binary.LittleEndian.Uint64(data), data[8:]
+	}
+	for i := range c.Mmu1 {
+		c.Mmu1[i], data = binary.LittleEndian.Uint64(data), data[8:]
+	}
+
+	x, err := NewModulusFromConstants(c, true)
+
+	if err != nil {
+		return err
+	}
+
+	z.m, z.mu, z.mmu0, z.mmu1 = x.m, x.mu, x.mmu0, x.mmu1
+
+	z.initReduction()
+
+	return nil
+}
+
+func appendUint64LE(b []byte, x uint64) []byte {
+	var t [8]byte
+
+	binary.LittleEndian.PutUint64(t[:], x)
+
+	return append(b, t[:]...)
+}
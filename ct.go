@@ -0,0 +1,339 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// The CT methods are constant-time variants of the corresponding methods without the suffix.
+// They give the same residue classes, but use masked selects instead of branches on
+// carries and borrows, and a fixed number of subtractions in the final reductions.
+// Their running time depends only on the modulus, which is not considered secret.
+//
+// This assumes that the platform multiplies 64-bit integers in constant time.
+//
+// Building with the mod256ct tag makes Add, Sub, Neg, Double, Mul, MulUint64, Square, ExpPrecomp,
+// Equal, NotEqual, ToUint64, IsZero and IsOne constant-time as well, by routing them and the
+// internal reductions through the constant-time variants.
+
+// AddCT computes the sum of two residues in constant time.
+func (z *Residue) AddCT(x *Residue) *Residue {
+	if z.m != x.m {
+		if z.m.m != x.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	t0, c := Add64(z.r[0], x.r[0], 0)
+	t1, c := Add64(z.r[1], x.r[1], c)
+	t2, c := Add64(z.r[2], x.r[2], c)
+	t3, c := Add64(z.r[3], x.r[3], c)
+
+	u0, b := Sub64(t0, z.m.mmu1[0], 0)
+	u1, b := Sub64(t1, z.m.mmu1[1], b)
+	u2, b := Sub64(t2, z.m.mmu1[2], b)
+	u3, _ := Sub64(t3, z.m.mmu1[3], b)
+
+	v0, b := Sub64(t0, z.m.mmu0[0], 0)
+	v1, b := Sub64(t1, z.m.mmu0[1], b)
+	v2, b := Sub64(t2, z.m.mmu0[2], b)
+	v3, b := Sub64(t3, z.m.mmu0[3], b)
+
+	// On overflow subtract the larger multiple of m if possible, otherwise the smaller one
+
+	s := b - 1 // all ones iff no borrow
+
+	v0, v1, v2, v3 = v0 ^ (s & (v0 ^ u0)), v1 ^ (s & (v1 ^ u1)), v2 ^ (s & (v2 ^ u2)), v3 ^ (s & (v3 ^ u3))
+
+	s = -c
+
+	z.r[0], z.r[1], z.r[2], z.r[3] = t0 ^ (s & (t0 ^ v0)), t1 ^ (s & (t1 ^ v1)), t2 ^ (s & (t2 ^ v2)), t3 ^ (s & (t3 ^ v3))
+
+	return z
+}
+
+// SubCT computes the sum of a residue and the negation of a second residue in constant time.
+func (z *Residue) SubCT(x *Residue) *Residue {
+	if z.m != x.m {
+		if z.m.m != x.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	t0, b := Sub64(z.r[0], x.r[0], 0)
+	t1, b := Sub64(z.r[1], x.r[1], b)
+	t2, b := Sub64(z.r[2], x.r[2], b)
+	t3, b := Sub64(z.r[3], x.r[3], b)
+
+	u0, c := Add64(t0, z.m.mmu1[0], 0)
+	u1, c := Add64(t1, z.m.mmu1[1], c)
+	u2, c := Add64(t2, z.m.mmu1[2], c)
+	u3, _ := Add64(t3, z.m.mmu1[3], c)
+
+	v0, c := Add64(t0, z.m.mmu0[0], 0)
+	v1, c := Add64(t1, z.m.mmu0[1], c)
+	v2, c := Add64(t2, z.m.mmu0[2], c)
+	v3, c := Add64(t3, z.m.mmu0[3], c)
+
+	// On underflow add the larger multiple of m if possible, otherwise the smaller one
+
+	s := c - 1 // all ones iff no carry
+
+	v0, v1, v2, v3 = v0 ^ (s & (v0 ^ u0)), v1 ^ (s & (v1 ^ u1)), v2 ^ (s & (v2 ^ u2)), v3 ^ (s & (v3 ^ u3))
+
+	s = -b
+
+	z.r[0], z.r[1], z.r[2], z.r[3] = t0 ^ (s & (t0 ^ v0)), t1 ^ (s & (t1 ^ v1)), t2 ^ (s & (t2 ^ v2)), t3 ^ (s & (t3 ^ v3))
+
+	return z
+}
+
+// NegCT computes the negation (additive inverse) of a residue in constant time.
+func (z *Residue) NegCT() *Residue {
+	t0, b := Sub64(z.m.mmu0[0], z.r[0], 0)
+	t1, b := Sub64(z.m.mmu0[1], z.r[1], b)
+	t2, b := Sub64(z.m.mmu0[2], z.r[2], b)
+	t3, b := Sub64(z.m.mmu0[3], z.r[3], b)
+
+	u0, d := Sub64(z.m.mmu1[0], z.r[0], 0)
+	u1, d := Sub64(z.m.mmu1[1], z.r[1], d)
+	u2, d := Sub64(z.m.mmu1[2], z.r[2], d)
+	u3, _ := Sub64(z.m.mmu1[3], z.r[3], d)
+
+	// Use the larger multiple of m if the smaller one is too small
+
+	s := -b
+
+	z.r[0], z.r[1], z.r[2], z.r[3] = t0 ^ (s & (t0 ^ u0)), t1 ^ (s & (t1 ^ u1)), t2 ^ (s & (t2 ^ u2)), t3 ^ (s & (t3 ^ u3))
+
+	return z
+}
+
+// DoubleCT computes the double of a residue in constant time.
+func (z *Residue) DoubleCT() *Residue {
+	return z.AddCT(z)
+}
+
+// MulCT computes the product of two residues in constant time.
+func (z *Residue) MulCT(x *Residue) *Residue {
+	var p [8]uint64
+
+	if z.m != x.m {
+		if z.m.m != x.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	mul512(&p, &z.r, &x.r)
+
+	return z.reduce8CT(p)
+}
+
+// SquareCT computes the square of a residue in constant time.
+func (z *Residue) SquareCT() *Residue {
+	var p [8]uint64
+
+	sqr512(&p, &z.r)
+
+	return z.reduce8CT(p)
+}
+
+// EqualCT compares one residue to another in constant time, returns true when equal.
+func (x *Residue) EqualCT(y *Residue) bool {
+	u := x.ToUint64CT()
+	v := y.ToUint64CT()
+
+	d := x.m.m[0] ^ y.m.m[0]
+	d |= x.m.m[1] ^ y.m.m[1]
+	d |= x.m.m[2] ^ y.m.m[2]
+	d |= x.m.m[3] ^ y.m.m[3]
+
+	d |= u[0] ^ v[0]
+	d |= u[1] ^ v[1]
+	d |= u[2] ^ v[2]
+	d |= u[3] ^ v[3]
+
+	return d == 0
+}
+
+// ToUint64CT returns an array with the canonical representative of the residue class, computed in constant time.
+func (z *Residue) ToUint64CT() [4]uint64 {
+	z.reduce4CT() // Reduce to canonical residue
+	return z.r
+}
+
+// reduce8CT computes a 256-bit residue of x modulo z.m in constant time
+// and stores it in z
+func (z *Residue) reduce8CT(x [8]uint64) *Residue {
+
+	// Same quotient estimate as reduce8
+
+	mu := z.m.mu
+	m := z.m.m
+
+	// q1 = x/2^192
+
+	x0 := x[3]
+	x1 := x[4]
+	x2 := x[5]
+	x3 := x[6]
+	x4 := x[7]
+
+	// q2 = q1 * mu; q3 = q2 / 2^320
+
+	var q0, q1, q2, q3, q4, q5, t0, t1, c uint64
+
+	q0, _  = Mul64(x3, mu[0])
+	q1, t0 = Mul64(x4, mu[0]); q0, c = Add64(q0, t0, 0); q1, _ = Add64(q1,  0, c)
+
+
+	t1, _  = Mul64(x2, mu[1]); q0, c = Add64(q0, t1, 0)
+	q2, t0 = Mul64(x4, mu[1]); q1, c = Add64(q1, t0, c); q2, _ = Add64(q2,  0, c)
+
+	t1, t0 = Mul64(x3, mu[1]); q0, c = Add64(q0, t0, 0); q1, c = Add64(q1, t1, c); q2, _ = Add64(q2, 0, c)
+
+
+	t1, t0 = Mul64(x2, mu[2]); q0, c = Add64(q0, t0, 0); q1, c = Add64(q1, t1, c)
+	q3, t0 = Mul64(x4, mu[2]); q2, c = Add64(q2, t0, c); q3, _ = Add64(q3,  0, c)
+
+	t1, _  = Mul64(x1, mu[2]); q0, c = Add64(q0, t1, 0)
+	t1, t0 = Mul64(x3, mu[2]); q1, c = Add64(q1, t0, c); q2, c = Add64(q2, t1, c); q3, _ = Add64(q3, 0, c)
+
+
+	t1, _  = Mul64(x0, mu[3]); q0, c = Add64(q0, t1, 0)
+	t1, t0 = Mul64(x2, mu[3]); q1, c = Add64(q1, t0, c); q2, c = Add64(q2, t1, c)
+	q4, t0 = Mul64(x4, mu[3]); q3, c = Add64(q3, t0, c); q4, _ = Add64(q4,  0, c)
+
+	t1, t0 = Mul64(x1, mu[3]); q0, c = Add64(q0, t0, 0); q1, c = Add64(q1, t1, c)
+	t1, t0 = Mul64(x3, mu[3]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c); q4, _ = Add64(q4, 0, c)
+
+
+	t1, t0 = Mul64(x0, mu[4]); _,  c = Add64(q0, t0, 0); q1, c = Add64(q1, t1, c)
+	t1, t0 = Mul64(x2, mu[4]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c)
+	q5, t0 = Mul64(x4, mu[4]); q4, c = Add64(q4, t0, c); q5, _ = Add64(q5,  0, c)
+
+	t1, t0 = Mul64(x1, mu[4]); q1, c = Add64(q1, t0, 0); q2, c = Add64(q2, t1, c)
+	t1, t0 = Mul64(x3, mu[4]); q3, c = Add64(q3, t0, c); q4, c = Add64(q4, t1, c); q5, _ = Add64(q5, 0, c)
+
+	// Drop the fractional part of q3
+
+	q0 = q1
+	q1 = q2
+	q2 = q3
+	q3 = q4
+	q4 = q5
+
+	// r1 = x mod 2^320
+
+	x0 = x[0]
+	x1 = x[1]
+	x2 = x[2]
+	x3 = x[3]
+	x4 = x[4]
+
+	// r2 = q3 * m mod 2^320
+
+	var r0, r1, r2, r3, r4 uint64
+
+	r4, r3 = Mul64(q0, m[3])
+	_,  t0 = Mul64(q1, m[3]); r4, _ = Add64(r4, t0, 0)
+
+
+	t1, r2 = Mul64(q0, m[2]); r3, c = Add64(r3, t1, 0)
+	_,  t0 = Mul64(q2, m[2]); r4, _ = Add64(r4, t0, c)
+
+	t1, t0 = Mul64(q1, m[2]); r3, c = Add64(r3, t0, 0); r4, _ = Add64(r4, t1, c)
+
+
+	t1, r1 = Mul64(q0, m[1]); r2, c = Add64(r2, t1, 0)
+	t1, t0 = Mul64(q2, m[1]); r3, c = Add64(r3, t0, c); r4, _ = Add64(r4, t1, c)
+
+	t1, t0 = Mul64(q1, m[1]); r2, c = Add64(r2, t0, 0); r3, c = Add64(r3, t1, c)
+	_,  t0 = Mul64(q3, m[1]); r4, _ = Add64(r4, t0, c)
+
+
+	t1, r0 = Mul64(q0, m[0]); r1, c = Add64(r1, t1, 0)
+	t1, t0 = Mul64(q2, m[0]); r2, c = Add64(r2, t0, c); r3, c = Add64(r3, t1, c)
+	_,  t0 = Mul64(q4, m[0]); r4, _ = Add64(r4, t0, c)
+
+	t1, t0 = Mul64(q1, m[0]); r1, c = Add64(r1, t0, 0); r2, c = Add64(r2, t1, c)
+	t1, t0 = Mul64(q3, m[0]); r3, c = Add64(r3, t0, c); r4, _ = Add64(r4, t1, c)
+
+
+	// r = r1 - r2
+
+	var b uint64
+
+	r0, b = Sub64(x0, r0, 0)
+	r1, b = Sub64(x1, r1, b)
+	r2, b = Sub64(x2, r2, b)
+	r3, b = Sub64(x3, r3, b)
+	r4, _ = Sub64(x4, r4, b)
+
+	// r < 3m, so two subtractions of m bring it below 2^256
+	// Commit each subtraction if r >= 2^256
+
+	for i := 0; i < 2; i++ {
+		x0, b = Sub64(r0, m[0], 0)
+		x1, b = Sub64(r1, m[1], b)
+		x2, b = Sub64(r2, m[2], b)
+		x3, b = Sub64(r3, m[3], b)
+		x4, _ = Sub64(r4,    0, b)
+
+		s := -((r4 | -r4) >> 63) // all ones iff r4 != 0
+
+		r0, r1, r2, r3, r4 = r0 ^ (s & (r0 ^ x0)), r1 ^ (s & (r1 ^ x1)), r2 ^ (s & (r2 ^ x2)), r3 ^ (s & (r3 ^ x3)), r4 ^ (s & (r4 ^ x4))
+	}
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = r3, r2, r1, r0
+
+	return z
+}
+
+// reduce4CT computes the least non-negative residue of z in constant time
+// and stores it back in z
+func (z *Residue) reduce4CT() *Residue {
+
+	// Same quotient estimate as reduce4
+
+	var x0, x1, x2, x3, x4, r0, r1, r2, r3, r4, q3, t0, t1, c, b uint64
+
+	mu := z.m.mu
+	m  := z.m.m
+
+	q3, _ = Mul64(z.r[3], mu[4])
+
+	r2, r1 = Mul64(q3, m[1])
+	r4, r3 = Mul64(q3, m[3])
+
+	t1, r0 = Mul64(q3, m[0]); r1, c = Add64(r1, t1, 0)
+	t1, t0 = Mul64(q3, m[2]); r2, c = Add64(r2, t0, c); r3, c = Add64(r3, t1, c); r4, _ = Add64(r4, 0, c)
+
+	r0, b = Sub64(z.r[0], r0, 0)
+	r1, b = Sub64(z.r[1], r1, b)
+	r2, b = Sub64(z.r[2], r2, b)
+	r3, b = Sub64(z.r[3], r3, b)
+	r4, _ = Sub64(     0, r4, b)
+
+	// r < 4m, so three subtractions of m make it canonical
+	// Commit each subtraction if there is no borrow
+
+	for i := 0; i < 3; i++ {
+		x0, b = Sub64(r0, m[0], 0)
+		x1, b = Sub64(r1, m[1], b)
+		x2, b = Sub64(r2, m[2], b)
+		x3, b = Sub64(r3, m[3], b)
+		x4, b = Sub64(r4,    0, b)
+
+		s := b - 1 // all ones iff no borrow
+
+		r0, r1, r2, r3, r4 = r0 ^ (s & (r0 ^ x0)), r1 ^ (s & (r1 ^ x1)), r2 ^ (s & (r2 ^ x2)), r3 ^ (s & (r3 ^ x3)), r4 ^ (s & (r4 ^ x4))
+	}
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = r3, r2, r1, r0
+
+	return z
+}
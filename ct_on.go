@@ -0,0 +1,11 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build mod256ct
+// +build mod256ct
+
+package mod256
+
+// constantTime selects the constant-time paths listed in ct.go.
+const constantTime = true
@@ -10,6 +10,9 @@ import (
 
 // Double computes the double of a residue.
 func (z *Residue) Double() *Residue {
+	if constantTime {
+		return z.DoubleCT()
+	}
 
 	t0, c := Add64(z.r[0], z.r[0], 0)
 	t1, c := Add64(z.r[1], z.r[1], c)
@@ -0,0 +1,77 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"errors"
+)
+
+// Errors returned by the library, and used as panic values by the methods that panic.
+// They can be tested for with errors.Is.
+var (
+	ErrModulusTooSmall    = errors.New("Modulus < 2^192")
+	ErrModulusTooLarge    = errors.New("Modulus >= 2^256")
+	ErrIncompatibleModuli = errors.New("Incompatible moduli")
+	ErrUninitialized      = errors.New("Uninitialized residue")
+	ErrNonCanonical       = errors.New("Residue >= modulus")
+	ErrEvenModulus        = errors.New("Even modulus")
+	ErrExpBaseGeometry    = errors.New("Invalid ExpBase geometry")
+)
+
+// CheckCompatible returns nil if z and x are initialized residues with the same modulus,
+// and otherwise the error that makes binary operations on them fail.
+func (z *Residue) CheckCompatible(x *Residue) error {
+	if z.m == nil || x.m == nil {
+		return ErrUninitialized
+	}
+
+	if z.m != x.m {
+		if z.m.m != x.m.m {
+			return ErrIncompatibleModuli
+		}
+	}
+
+	return nil
+}
+
+// FromUint64E is like FromUint64, but returns an error instead of panicking.
+func (z *Residue) FromUint64E(m *Modulus, x [4]uint64) (*Residue, error) {
+	if m == nil {
+		return z, ErrUninitialized
+	}
+
+	if m.m[3] == 0 {
+		return z, ErrModulusTooSmall
+	}
+
+	return z.FromUint64(m, x), nil
+}
+
+// AddE is like Add, but returns an error instead of panicking.
+func (z *Residue) AddE(x *Residue) (*Residue, error) {
+	if err := z.CheckCompatible(x); err != nil {
+		return z, err
+	}
+
+	return z.Add(x), nil
+}
+
+// SubE is like Sub, but returns an error instead of panicking.
+func (z *Residue) SubE(x *Residue) (*Residue, error) {
+	if err := z.CheckCompatible(x); err != nil {
+		return z, err
+	}
+
+	return z.Sub(x), nil
+}
+
+// MulE is like Mul, but returns an error instead of panicking.
+func (z *Residue) MulE(x *Residue) (*Residue, error) {
+	if err := z.CheckCompatible(x); err != nil {
+		return z, err
+	}
+
+	return z.Mul(x), nil
+}
@@ -4,95 +4,165 @@
 
 package mod256
 
+import (
+	"math/big"
+	. "math/bits"
+)
+
 // The ExpBase type contains lookup tables allowing fast repeated modular exponentiation with the same base value.
+//
+// The exponent bits are split into teeth*tables evenly spaced rows, and each table
+// of 2^teeth entries covers teeth rows. FromResidue uses 2 tables with 4 teeth and
+// needs no allocation, while NewExpBase allows other geometries.
+// Geometries with at most 32 entries are stored in the ExpBase itself.
 type ExpBase struct {
-	h, l [16]Residue
+	t       []Residue   // tables with 2^teeth entries each, nil when stored in fixed
+	fixed   [32]Residue // tables of geometries with at most 32 entries
+	teeth   int
+	tables  int
+	spacing int         // distance between the rows
 }
 
-// FromResidue initialises ExpBase from a residue.
-// It performs 224 squarings and 22 multiplications.
-func (z *ExpBase) FromResidue(x *Residue) *ExpBase {
+// NewExpBase creates an ExpBase from a residue, with tables of 2^teeth entries.
+// Teeth must be from 1 to 8, and teeth*tables at most 256.
+// More entries means fewer multiplications per exponentiation, see ExpBaseCost,
+// but tables that do not fit in the cache give less than the counts suggest.
+// It performs (teeth*tables - 1)*spacing squarings and tables*(2^teeth - teeth - 1) multiplications,
+// with spacing = ceil(256 / (teeth*tables)).
+func NewExpBase(x *Residue, teeth, tables int) (*ExpBase, error) {
+	if !validGeometry(teeth, tables) {
+		return nil, ErrExpBaseGeometry
+	}
+
+	return new(ExpBase).init(x, teeth, tables), nil
+}
+
+// init computes the tables of z for a valid geometry.
+func (z *ExpBase) init(x *Residue, teeth, tables int) *ExpBase {
 	var r Residue
 
+	n := 1 << uint(teeth)
+
+	z.t = nil
+
+	if tables*n > len(z.fixed) {
+		z.t = make([]Residue, tables*n)
+	}
+
+	z.teeth, z.tables = teeth, tables
+	z.spacing = (256 + teeth*tables - 1) / (teeth*tables)
+
 	r.Copy(x)
 
-	z.l[0].m = r.m
-	z.l[0].r = [4]uint64{1,0,0,0}
+	e := z.entries()
 
-	z.l[1].Copy(&r)
+	for j := 0; j < tables; j++ {
+		t := e[j*n : (j+1)*n]
 
-	for i:=0; i<32; i++ {
-		r.Square()
-	}
+		t[0].SetOne(r.m)
 
-	z.l[2].Copy(&r)
-	z.l[3].Copy(&r).Mul(&z.l[1])
+		for i := 0; i < teeth; i++ {
+			if i > 0 || j > 0 {
+				for k := 0; k < z.spacing; k++ {
+					r.Square()
+				}
+			}
 
-	for i:=0; i<32; i++ {
-		r.Square()
-	}
+			// r = x^(2^((j*teeth + i)*spacing)), and entries with bit i set are r times the ones below
 
-	z.l[4].Copy(&r)
-	z.l[5].Copy(&r).Mul(&z.l[1])
-	z.l[6].Copy(&r).Mul(&z.l[2])
-	z.l[7].Copy(&r).Mul(&z.l[3])
+			t[1 << uint(i)].Copy(&r)
 
-	for i:=0; i<32; i++ {
-		r.Square()
+			for u := 1; u < 1 << uint(i); u++ {
+				t[1 << uint(i) + u].Copy(&r).Mul(&t[u])
+			}
+		}
 	}
 
-	z.l[ 8].Copy(&r)
-	z.l[ 9].Copy(&r).Mul(&z.l[1])
-	z.l[10].Copy(&r).Mul(&z.l[2])
-	z.l[11].Copy(&r).Mul(&z.l[3])
-	z.l[12].Copy(&r).Mul(&z.l[4])
-	z.l[13].Copy(&r).Mul(&z.l[5])
-	z.l[14].Copy(&r).Mul(&z.l[6])
-	z.l[15].Copy(&r).Mul(&z.l[7])
+	return z
+}
 
-	for i:=0; i<32; i++ {
-		r.Square()
+// ExpBaseCost returns the number of table entries (residues) for an ExpBase geometry,
+// and the number of squarings and multiplications performed by ExpPrecomp with it.
+// FromResidue corresponds to 4 teeth and 2 tables.
+// It returns ErrExpBaseGeometry for geometries rejected by NewExpBase.
+func ExpBaseCost(teeth, tables int) (entries, squarings, multiplications int, err error) {
+	if !validGeometry(teeth, tables) {
+		return 0, 0, 0, ErrExpBaseGeometry
 	}
 
-	z.h[0].Copy(&z.l[0])
+	spacing := (256 + teeth*tables - 1) / (teeth*tables)
 
-	z.h[1].Copy(&r)
+	return tables << uint(teeth), spacing - 1, tables*spacing - 1, nil
+}
 
-	for i:=0; i<32; i++ {
-		r.Square()
-	}
+// validGeometry reports whether an ExpBase can have the given number of teeth and tables.
+func validGeometry(teeth, tables int) bool {
+	return teeth >= 1 && teeth <= 8 && tables >= 1 && teeth*tables <= 256
+}
 
-	z.h[2].Copy(&r)
-	z.h[3].Copy(&r).Mul(&z.h[1])
+// FromResidue initialises ExpBase from a residue, with 2 tables of 16 entries.
+// It performs 224 squarings and 22 multiplications.
+func (z *ExpBase) FromResidue(x *Residue) *ExpBase {
+	return z.init(x, 4, 2)
+}
 
-	for i:=0; i<32; i++ {
-		r.Square()
+// ExpPrecomp takes an ExpBase computed from the base value, a 256-bit integer as the exponent, and performs modular exponentiation.
+// With an ExpBase from FromResidue it performs 31 squarings and 63 multiplications.
+func (z *Residue) ExpPrecomp(x *ExpBase, y [4]uint64) *Residue {
+	if constantTime {
+		return z.ExpPrecompCT(x, y)
 	}
 
-	z.h[4].Copy(&r)
-	z.h[5].Copy(&r).Mul(&z.h[1])
-	z.h[6].Copy(&r).Mul(&z.h[2])
-	z.h[7].Copy(&r).Mul(&z.h[3])
-
-	for i:=0; i<32; i++ {
-		r.Square()
+	if x.teeth != 4 || x.tables != 2 {
+		return z.expComb(x, y)
 	}
 
-	z.h[ 8].Copy(&r)
-	z.h[ 9].Copy(&r).Mul(&z.h[1])
-	z.h[10].Copy(&r).Mul(&z.h[2])
-	z.h[11].Copy(&r).Mul(&z.h[3])
-	z.h[12].Copy(&r).Mul(&z.h[4])
-	z.h[13].Copy(&r).Mul(&z.h[5])
-	z.h[14].Copy(&r).Mul(&z.h[6])
-	z.h[15].Copy(&r).Mul(&z.h[7])
+	lo, hi := x.fixed[:16], x.fixed[16:]
+
+	h :=	((y[3] >> 60) & 8) |
+		((y[3] >> 29) & 4) |
+		((y[2] >> 62) & 2) |
+		((y[2] >> 31) & 1)
+
+	l :=	((y[1] >> 60) & 8) |
+		((y[1] >> 29) & 4) |
+		((y[0] >> 62) & 2) |
+		((y[0] >> 31) & 1)
+
+	z.Copy(&hi[h]).Mul(&lo[l])
+
+	for i := 1; i<32; i++ {
+		y[3] <<= 1
+		y[2] <<= 1
+		y[1] <<= 1
+		y[0] <<= 1
+
+		h =	((y[3] >> 60) & 8) |
+			((y[3] >> 29) & 4) |
+			((y[2] >> 62) & 2) |
+			((y[2] >> 31) & 1)
+
+		l =	((y[1] >> 60) & 8) |
+			((y[1] >> 29) & 4) |
+			((y[0] >> 62) & 2) |
+			((y[0] >> 31) & 1)
+
+		z.Square().Mul(&hi[h]).Mul(&lo[l])
+	}
 
 	return z
 }
 
-// ExpPrecomp takes an ExpBase computed from the base value, a 256-bit integer as the exponent, and performs modular exponentiation.
-// It performs 31 squarings and 63 multiplications.
-func (z *Residue) ExpPrecomp(x *ExpBase, y [4]uint64) *Residue {
+// ExpPrecompCT is a constant-time variant of ExpPrecomp.
+// The table entries are read with LookupTable, and the arithmetic uses SquareCT and MulCT.
+func (z *Residue) ExpPrecompCT(x *ExpBase, y [4]uint64) *Residue {
+	var t Residue
+
+	if x.teeth != 4 || x.tables != 2 {
+		return z.expCombCT(x, y)
+	}
+
+	lo, hi := x.fixed[:16], x.fixed[16:]
 
 	h :=	((y[3] >> 60) & 8) |
 		((y[3] >> 29) & 4) |
@@ -104,7 +174,7 @@ func (z *Residue) ExpPrecomp(x *ExpBase, y [4]uint64) *Residue {
 		((y[0] >> 62) & 2) |
 		((y[0] >> 31) & 1)
 
-	z.Copy(&x.h[h]).Mul(&x.l[l])
+	z.LookupTable(hi, int(h)).MulCT(t.LookupTable(lo, int(l)))
 
 	for i := 1; i<32; i++ {
 		y[3] <<= 1
@@ -122,12 +192,86 @@ func (z *Residue) ExpPrecomp(x *ExpBase, y [4]uint64) *Residue {
 			((y[0] >> 62) & 2) |
 			((y[0] >> 31) & 1)
 
-		z.Square().Mul(&x.h[h]).Mul(&x.l[l])
+		z.SquareCT().MulCT(t.LookupTable(hi, int(h))).MulCT(t.LookupTable(lo, int(l)))
 	}
 
 	return z
 }
 
+// expComb performs modular exponentiation with an ExpBase of any geometry.
+func (z *Residue) expComb(x *ExpBase, y [4]uint64) *Residue {
+	z.Copy(&x.table(0)[x.index(&y, 0, x.spacing-1)])
+
+	for j := 1; j < x.tables; j++ {
+		z.Mul(&x.table(j)[x.index(&y, j, x.spacing-1)])
+	}
+
+	for c := x.spacing-2; c >= 0; c-- {
+		z.Square()
+
+		for j := 0; j < x.tables; j++ {
+			z.Mul(&x.table(j)[x.index(&y, j, c)])
+		}
+	}
+
+	return z
+}
+
+// expCombCT is a constant-time variant of expComb.
+func (z *Residue) expCombCT(x *ExpBase, y [4]uint64) *Residue {
+	var t Residue
+
+	z.LookupTable(x.table(0), x.index(&y, 0, x.spacing-1))
+
+	for j := 1; j < x.tables; j++ {
+		z.MulCT(t.LookupTable(x.table(j), x.index(&y, j, x.spacing-1)))
+	}
+
+	for c := x.spacing-2; c >= 0; c-- {
+		z.SquareCT()
+
+		for j := 0; j < x.tables; j++ {
+			z.MulCT(t.LookupTable(x.table(j), x.index(&y, j, c)))
+		}
+	}
+
+	return z
+}
+
+// table returns table j, with 2^teeth entries.
+func (x *ExpBase) table(j int) []Residue {
+	return x.entries()[j << uint(x.teeth) : (j+1) << uint(x.teeth)]
+}
+
+// entries returns all tables, which are stored in fixed when t is nil.
+func (x *ExpBase) entries() []Residue {
+	if x.t == nil {
+		return x.fixed[:x.tables << uint(x.teeth)]
+	}
+
+	return x.t
+}
+
+// index returns the entry of table j for column c of the exponent rows.
+// Tooth i of table j reads bit (j*teeth + i)*spacing + c, and bits above 255 are 0.
+func (x *ExpBase) index(y *[4]uint64, j, c int) int {
+	v := 0
+	d := uint(x.spacing)
+	p := uint((j+1)*x.teeth - 1)*d + uint(c)
+
+	for i := 0; i < x.teeth; i++ {
+		v <<= 1
+
+		if p < 256 {
+			v |= int(y[p >> 6] >> (p & 63) & 1)
+		}
+
+		p -= d
+	}
+
+	return v
+}
+
 // Exp performs modular exponentiation without storing precomputed values for later use.
 // It performs 255 squarings and 74 multiplications.
 func (z *Residue) Exp(x [4]uint64) *Residue {
@@ -138,8 +282,7 @@ func (z *Residue) Exp(x [4]uint64) *Residue {
 
 	r.Copy(z)
 
-	t[0].m = r.m
-	t[0].r = [4]uint64{1,0,0,0}
+	t[0].SetOne(r.m)
 
 	t[1].Copy(z)
 
@@ -197,3 +340,119 @@ func (z *Residue) Exp(x [4]uint64) *Residue {
 
 	return z
 }
+
+// ExpUint64 performs modular exponentiation with a 64-bit exponent, using a sliding window.
+func (z *Residue) ExpUint64(x uint64) *Residue {
+	return z.expSliding(Len64(x), func(i int) uint64 {
+		return x >> uint(i) & 1
+	})
+}
+
+// ExpBytes performs modular exponentiation with a big-endian byte slice of any length as the exponent, using a sliding window.
+func (z *Residue) ExpBytes(x []byte) *Residue {
+	for len(x) > 0 && x[0] == 0 {
+		x = x[1:]
+	}
+
+	n := 0
+	if len(x) > 0 {
+		n = 8*len(x) - LeadingZeros8(x[0])
+	}
+
+	return z.expSliding(n, func(i int) uint64 {
+		return uint64(x[len(x)-1-i/8] >> uint(i%8) & 1)
+	})
+}
+
+// ExpBig performs modular exponentiation with a big.Int of any length as the exponent, using a sliding window.
+// A negative exponent gives a power of the inverse, or 0 if the inverse does not exist.
+func (z *Residue) ExpBig(x *big.Int) *Residue {
+	if x.Sign() < 0 {
+		z.Inv()
+	}
+
+	w := x.Bits()
+
+	return z.expSliding(x.BitLen(), func(i int) uint64 {
+		return limb(w, i/64) >> uint(i%64) & 1
+	})
+}
+
+// expSliding performs left-to-right sliding window exponentiation with an n-bit exponent, given one bit at a time.
+// The window size grows with n, and leading zero bits are skipped.
+// It performs n-1 squarings, 2^(w-1) multiplications for the table, and about n/(w+1) further multiplications.
+func (z *Residue) expSliding(n int, bit func(i int) uint64) *Residue {
+	var (
+		r Residue
+		t [32]Residue // x, x^3, x^5, ..., x^(2^w - 1)
+	)
+
+	if n == 0 {
+		return z.SetOne(z.m)
+	}
+
+	w := 1
+
+	switch {
+	case n > 671:
+		w = 6
+	case n > 239:
+		w = 5
+	case n > 79:
+		w = 4
+	case n > 23:
+		w = 3
+	}
+
+	t[0].Copy(z)
+
+	if w > 1 {
+		r.SquareOf(z)
+
+		for i := 1; i < 1 << uint(w-1); i++ {
+			t[i].Copy(&t[i-1]).Mul(&r)
+		}
+	}
+
+	// The most significant bit is 1, and starts the first window
+
+	for i, first := n-1, true; i >= 0; {
+		if bit(i) == 0 {
+			z.Square()
+			i--
+			continue
+		}
+
+		// The window is bits i down to j, with bit j set
+
+		j := i - w + 1
+		if j < 0 {
+			j = 0
+		}
+
+		for bit(j) == 0 {
+			j++
+		}
+
+		v := uint64(0)
+
+		for k := i; k >= j; k-- {
+			v = v << 1 | bit(k)
+
+			if !first {
+				z.Square()
+			}
+		}
+
+		if first {
+			z.Copy(&t[v >> 1])
+			first = false
+		} else {
+			z.Mul(&t[v >> 1])
+		}
+
+		i = j - 1
+	}
+
+	return z
+}
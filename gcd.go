@@ -0,0 +1,146 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"encoding/binary"
+	"math/big"
+	. "math/bits"
+)
+
+// Div divides a residue by a second residue.
+// Returns true if x is invertible, otherwise z is set to 0 and false is returned.
+func (z *Residue) Div(x *Residue) bool {
+	return z.Quotient(z, x)
+}
+
+// InvOrFactor computes the (multiplicative) inverse of a residue like Inv, and returns 1 and true if it exists.
+// Otherwise z is set to 0, and g = gcd(z, m) is returned with false.
+// Then g is a proper factor of m, unless z = 0 (mod m) and g = m.
+func (z *Residue) InvOrFactor() (g [4]uint64, ok bool) {
+	g, s, _ := extendedGCD(z.r, z.m.m)
+
+	if g != [4]uint64{ 1, 0, 0, 0 } {
+		z.r[3], z.r[2], z.r[1], z.r[0] = 0, 0, 0, 0
+		return g, false
+	}
+
+	z.r = reduceSigned(s, &z.m.m)
+
+	return g, true
+}
+
+// ExtendedGCD returns g = gcd(a, m), using the binary extended GCD algorithm of Inv.
+// If s or t are not nil, they are set to Bézout coefficients such that g = a*s + m*t.
+// Like big.Int.GCD, it does not allocate if s and t already have room for 320 bits.
+// The gcd of 0 and 0 is 0.
+func ExtendedGCD(s, t *big.Int, a, m [4]uint64) (g [4]uint64) {
+	g, u, v := extendedGCD(a, m)
+
+	if s != nil {
+		setSigned(s, &u)
+	}
+
+	if t != nil {
+		setSigned(t, &v)
+	}
+
+	return g
+}
+
+// extendedGCD computes g = gcd(x, y), and s and t such that s*x + t*y = g, as 320-bit two's complement values.
+// Unlike binaryGCD it accepts any values, removing common factors of 2 before the binary GCD.
+func extendedGCD(x, y [4]uint64) (g [4]uint64, s, t [5]uint64) {
+	switch {
+	case x == [4]uint64{ 0, 0, 0, 0 }:
+		return y, s, [5]uint64{ 1, 0, 0, 0, 0 }
+	case y == [4]uint64{ 0, 0, 0, 0 }:
+		return x, [5]uint64{ 1, 0, 0, 0, 0 }, t
+	}
+
+	// k = min(trailing zeros of x and y), and gcd(x, y) = 2^k * gcd(x/2^k, y/2^k)
+
+	k := TrailingZeros64(x[0] | y[0])
+
+	if k == 64 {
+		k = 64 + TrailingZeros64(x[1] | y[1])
+		if k == 128 {
+			k = 128 + TrailingZeros64(x[2] | y[2])
+			if k == 192 {
+				k = 192 + TrailingZeros64(x[3] | y[3])
+			}
+		}
+	}
+
+	x = shiftRight(x, k)
+	y = shiftRight(y, k)
+
+	g, s, t = binaryGCD(&x, &y)
+
+	return shiftLeft(g, k), s, t
+}
+
+// shiftRight returns x/2^k for k < 256.
+func shiftRight(x [4]uint64, k int) [4]uint64 {
+	for ; k >= 64; k -= 64 {
+		x[0], x[1], x[2], x[3] = x[1], x[2], x[3], 0
+	}
+
+	if k > 0 {
+		x[0] = x[0] >> uint(k) | x[1] << uint(64 - k)
+		x[1] = x[1] >> uint(k) | x[2] << uint(64 - k)
+		x[2] = x[2] >> uint(k) | x[3] << uint(64 - k)
+		x[3] = x[3] >> uint(k)
+	}
+
+	return x
+}
+
+// shiftLeft returns x*2^k mod 2^256 for k < 256.
+func shiftLeft(x [4]uint64, k int) [4]uint64 {
+	for ; k >= 64; k -= 64 {
+		x[3], x[2], x[1], x[0] = x[2], x[1], x[0], 0
+	}
+
+	if k > 0 {
+		x[3] = x[3] << uint(k) | x[2] >> uint(64 - k)
+		x[2] = x[2] << uint(k) | x[1] >> uint(64 - k)
+		x[1] = x[1] << uint(k) | x[0] >> uint(64 - k)
+		x[0] = x[0] << uint(k)
+	}
+
+	return x
+}
+
+// setSigned sets z to the 320-bit two's complement value x, and returns z.
+func setSigned(z *big.Int, x *[5]uint64) *big.Int {
+	var (
+		b [40]byte
+		c uint64
+	)
+
+	y := *x
+	neg := y[4] >> 63 != 0
+
+	if neg {
+		y[0], c = Add64(^y[0], 1, 0)
+		y[1], c = Add64(^y[1], 0, c)
+		y[2], c = Add64(^y[2], 0, c)
+		y[3], c = Add64(^y[3], 0, c)
+		y[4], _ = Add64(^y[4], 0, c)
+	}
+
+	for i := 0; i < 5; i++ {
+		binary.BigEndian.PutUint64(b[32 - 8*i:], y[i])
+	}
+
+	z.SetBytes(b[:])
+
+	if neg {
+		z.Neg(z)
+	}
+
+	return z
+}
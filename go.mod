@@ -1,3 +1,5 @@
 module github.com/daosvik/mod256
 
 go 1.15
+
+require golang.org/x/sys v0.0.0-20210423082822-04245dca01da
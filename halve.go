@@ -0,0 +1,38 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// Halve computes the half of a residue, i.e. the product with the inverse of 2.
+// It panics with ErrEvenModulus if the modulus is even, as 2 then has no inverse.
+// The running time depends only on the modulus.
+func (z *Residue) Halve() *Residue {
+	var c uint64
+
+	m := &z.m.m
+
+	if m[0] & 1 == 0 {
+		panic(ErrEvenModulus)
+	}
+
+	// Add m if odd, then shift right
+
+	s := -(z.r[0] & 1)
+
+	t0, c := Add64(z.r[0], m[0] & s, 0)
+	t1, c := Add64(z.r[1], m[1] & s, c)
+	t2, c := Add64(z.r[2], m[2] & s, c)
+	t3, c := Add64(z.r[3], m[3] & s, c)
+
+	z.r[0] = t0 >> 1 | t1 << 63
+	z.r[1] = t1 >> 1 | t2 << 63
+	z.r[2] = t2 >> 1 | t3 << 63
+	z.r[3] = t3 >> 1 | c  << 63
+
+	return z
+}
@@ -12,7 +12,38 @@ import (
 // Returns true if the inverse exists
 
 // Inv computes the (multiplicative) inverse of a residue, if it exists.
+// It works for any modulus, odd or even, as the binary extended GCD only needs one of
+// the residue and the modulus to be odd. If both are even there is no inverse.
 func (z *Residue) Inv() bool {
+	x := z.r
+	y := z.m.m
+
+	if (x[3] | x[2] | x[1] | x[0]) == 0 ||	// u == 0
+	   (y[3] | y[2] | y[1] | y[0]) == 0 ||	// v == 0
+	   (x[0] | y[0]) & 1 == 0 {		// 2|gcd(u,v)
+		// there is no inverse
+		z.r[3], z.r[2], z.r[1], z.r[0] = 0, 0, 0, 0
+		return false
+	}
+
+	g, c, _ := binaryGCD(&x, &y)
+
+	if g != [4]uint64{ 1, 0, 0, 0 } { // gcd(z,m) != 1
+		z.r[3], z.r[2], z.r[1], z.r[0] = 0, 0, 0, 0
+		return false
+	}
+
+	z.r = reduceSigned(c, &y)
+
+	return true
+}
+
+// binaryGCD computes g = gcd(x, y), and s and t such that s*x + t*y = g,
+// using the binary extended GCD algorithm (HAC 14.61).
+// Both x and y must be nonzero, and at least one of them odd.
+// The halving steps only divide even coefficients, as long as x and y are not both even.
+// The coefficients are 320-bit two's complement values.
+func binaryGCD(x, y *[4]uint64) (g [4]uint64, s, t [5]uint64) {
 
 	var (
 		b, c, // Borrow & carry
@@ -22,20 +53,9 @@ func (z *Residue) Inv() bool {
 		d4, d3, d2, d1, d0 uint64
 	)
 
-	x := z.r
-	y := z.m.m
-
 	u3, u2, u1, u0 := x[3], x[2], x[1], x[0]
 	v3, v2, v1, v0 := y[3], y[2], y[1], y[0]
 
-	if (u3 | u2 | u1 | u0) == 0 ||	// u == 0
-	   (v3 | v2 | v1 | v0) == 0 ||	// v == 0
-	   (u0 | v0) & 1 == 0 {		// 2|gcd(u,v)
-		// there is no inverse
-		z.r[3], z.r[2], z.r[1], z.r[0] = 0, 0, 0, 0
-		return false
-	}
-
 	a4, a3, a2, a1, a0 = 0, 0, 0, 0, 1
 	b4, b3, b2, b1, b0 = 0, 0, 0, 0, 0
 	c4, c3, c2, c1, c0 = 0, 0, 0, 0, 0
@@ -160,12 +180,20 @@ func (z *Residue) Inv() bool {
 		}
 	}
 
-	if (v3 | v2 | v1 | (v0 - 1)) != 0 { // gcd(z,m) != 1
-		z.r[3], z.r[2], z.r[1], z.r[0] = 0, 0, 0, 0
-		return false
-	}
+	g = [4]uint64{ v0, v1, v2, v3 }
+	s = [5]uint64{ c0, c1, c2, c3, c4 }
+	t = [5]uint64{ d0, d1, d2, d3, d4 }
+
+	return g, s, t
+}
+
+// reduceSigned returns a 256-bit value congruent to the 320-bit two's complement value x modulo y.
+func reduceSigned(x [5]uint64, y *[4]uint64) [4]uint64 {
+	var b, c uint64
 
-	// Add or subtract modulus to find 256-bit inverse (<= 2 iterations expected)
+	c4, c3, c2, c1, c0 := x[4], x[3], x[2], x[1], x[0]
+
+	// Add or subtract modulus to find 256-bit value (<= 2 iterations expected)
 
 	for (c4 >> 63) != 0 {
 		c0, c = Add64(c0, y[0], 0)
@@ -183,7 +211,27 @@ func (z *Residue) Inv() bool {
 		c4, _ = Sub64(c4,    0, b)
 	}
 
-	z.r[3], z.r[2], z.r[1], z.r[0] = c3, c2, c1, c0
+	return [4]uint64{ c0, c1, c2, c3 }
+}
+
+// Quotient computes the quotient x/y of two residues, and stores it in z.
+// Any of x, y and z may be the same residue.
+// Returns true if y is invertible, otherwise z is set to 0 and false is returned.
+func (z *Residue) Quotient(x, y *Residue) bool {
+	var t Residue
+
+	if x.m != y.m {
+		if x.m.m != y.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	if !t.Copy(y).Inv() {
+		z.SetZero(x.m)
+		return false
+	}
+
+	z.Product(x, &t)
 
 	return true
 }
@@ -0,0 +1,106 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// InvCT computes the (multiplicative) inverse of a residue, if it exists, in constant time.
+// Returns true if the inverse exists, otherwise the residue is set to 0 and false is returned.
+// It panics if the modulus is even, which is not secret.
+//
+// The algorithm is the constant-time binary GCD of T. Pornin, "Optimized Binary GCD for
+// Modular Inversion" (Algorithm 1), with 511 iterations for 256-bit values.
+// Every iteration performs the same operations, with masks instead of branches.
+func (z *Residue) InvCT() bool {
+	var b, c, s uint64
+
+	y := z.m.m
+
+	if y[0] & 1 == 0 {
+		panic(ErrEvenModulus)
+	}
+
+	// Invariants: a = u*x (mod m), b = v*x (mod m)
+	// a and b are halved or subtracted until a = 0 and b = gcd(x, m)
+
+	a0, a1, a2, a3 := z.r[0], z.r[1], z.r[2], z.r[3]
+	b0, b1, b2, b3 := y[0], y[1], y[2], y[3]
+
+	u0, u1, u2, u3 := uint64(1), uint64(0), uint64(0), uint64(0)
+	v0, v1, v2, v3 := uint64(0), uint64(0), uint64(0), uint64(0)
+
+	for i:=0; i<511; i++ {
+
+		// If a is odd and a < b, then swap a with b and u with v
+
+		odd := -(a0 & 1)
+
+		_, b = Sub64(a0, b0, 0)
+		_, b = Sub64(a1, b1, b)
+		_, b = Sub64(a2, b2, b)
+		_, b = Sub64(a3, b3, b)
+
+		s = odd & -b
+
+		t0, t1, t2, t3 := s & (a0 ^ b0), s & (a1 ^ b1), s & (a2 ^ b2), s & (a3 ^ b3)
+
+		a0, a1, a2, a3 = a0 ^ t0, a1 ^ t1, a2 ^ t2, a3 ^ t3
+		b0, b1, b2, b3 = b0 ^ t0, b1 ^ t1, b2 ^ t2, b3 ^ t3
+
+		t0, t1, t2, t3 = s & (u0 ^ v0), s & (u1 ^ v1), s & (u2 ^ v2), s & (u3 ^ v3)
+
+		u0, u1, u2, u3 = u0 ^ t0, u1 ^ t1, u2 ^ t2, u3 ^ t3
+		v0, v1, v2, v3 = v0 ^ t0, v1 ^ t1, v2 ^ t2, v3 ^ t3
+
+		// If a is odd, then a = a - b and u = u - v (mod m)
+
+		a0, b = Sub64(a0, b0 & odd, 0)
+		a1, b = Sub64(a1, b1 & odd, b)
+		a2, b = Sub64(a2, b2 & odd, b)
+		a3, _ = Sub64(a3, b3 & odd, b)
+
+		u0, b = Sub64(u0, v0 & odd, 0)
+		u1, b = Sub64(u1, v1 & odd, b)
+		u2, b = Sub64(u2, v2 & odd, b)
+		u3, b = Sub64(u3, v3 & odd, b)
+
+		s = -b
+
+		u0, c = Add64(u0, y[0] & s, 0)
+		u1, c = Add64(u1, y[1] & s, c)
+		u2, c = Add64(u2, y[2] & s, c)
+		u3, _ = Add64(u3, y[3] & s, c)
+
+		// a = a/2, which is even, and u = u/2 (mod m)
+
+		a0 = (a0 >> 1) | (a1 << 63)
+		a1 = (a1 >> 1) | (a2 << 63)
+		a2 = (a2 >> 1) | (a3 << 63)
+		a3 = (a3 >> 1)
+
+		s = -(u0 & 1)
+
+		u0, c = Add64(u0, y[0] & s, 0)
+		u1, c = Add64(u1, y[1] & s, c)
+		u2, c = Add64(u2, y[2] & s, c)
+		u3, c = Add64(u3, y[3] & s, c)
+
+		u0 = (u0 >> 1) | (u1 << 63)
+		u1 = (u1 >> 1) | (u2 << 63)
+		u2 = (u2 >> 1) | (u3 << 63)
+		u3 = (u3 >> 1) | (c  << 63)
+	}
+
+	// b = gcd(x, m), and v = 1/x (mod m) if b = 1
+
+	g := b3 | b2 | b1 | (b0 ^ 1)
+	s = ((g | -g) >> 63) - 1 // all ones iff g = 0
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = v3 & s, v2 & s, v1 & s, v0 & s
+
+	return s != 0
+}
@@ -0,0 +1,99 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// Legendre computes the Legendre symbol of a residue modulo a prime, using exponentiation.
+// Returns 1 for non-zero quadratic residues, -1 for quadratic non-residues, and 0 for 0.
+// For composite moduli the result is 0 when the exponentiation reveals that the modulus is not prime.
+// It panics if the modulus is even.
+func (z *Residue) Legendre() int {
+	var t, one Residue
+
+	if z.m.m[0] & 1 == 0 {
+		panic(ErrEvenModulus)
+	}
+
+	// Euler's criterion: z^((m-1)/2)
+
+	t.Copy(z).Exp(shiftright256(z.m.m, 1))
+
+	if t.IsOne() {
+		return 1
+	}
+
+	if t.Add(one.SetOne(z.m)).IsZero() {
+		return -1
+	}
+
+	return 0
+}
+
+// Jacobi computes the Jacobi symbol of a residue modulo any odd modulus, using a binary algorithm.
+// Returns 1, -1, or 0 when the residue and the modulus are not coprime.
+// It panics if the modulus is even.
+func (z *Residue) Jacobi() int {
+	var b uint64
+
+	if z.m.m[0] & 1 == 0 {
+		panic(ErrEvenModulus)
+	}
+
+	x := z.ToUint64()
+	y := z.m.m
+
+	a3, a2, a1, a0 := x[3], x[2], x[1], x[0]
+	n3, n2, n1, n0 := y[3], y[2], y[1], y[0]
+
+	s := uint64(0) // Sign flips in bit 0
+
+	for (a3 | a2 | a1 | a0) != 0 {
+
+		// Remove factors of 2 from a, whole words first
+		// (2/n) = -1 iff n = 3 or 5 (mod 8)
+
+		for a0 == 0 {
+			a0, a1, a2, a3 = a1, a2, a3, 0
+		}
+
+		k := uint(TrailingZeros64(a0))
+
+		a0 = (a0 >> k) | (a1 << (64-k))
+		a1 = (a1 >> k) | (a2 << (64-k))
+		a2 = (a2 >> k) | (a3 << (64-k))
+		a3 = (a3 >> k)
+
+		s ^= uint64(k) & ((n0 >> 1) ^ (n0 >> 2))
+
+		// Both a and n are odd: make a >= n
+		// (a/n) = -(n/a) iff a = n = 3 (mod 4)
+
+		_, b = Sub64(a0, n0, 0)
+		_, b = Sub64(a1, n1, b)
+		_, b = Sub64(a2, n2, b)
+		_, b = Sub64(a3, n3, b)
+
+		if b != 0 { // a < n
+			a3, a2, a1, a0, n3, n2, n1, n0 = n3, n2, n1, n0, a3, a2, a1, a0
+			s ^= (a0 >> 1) & (n0 >> 1)
+		}
+
+		// a = a - n, which is even
+
+		a0, b = Sub64(a0, n0, 0)
+		a1, b = Sub64(a1, n1, b)
+		a2, b = Sub64(a2, n2, b)
+		a3, _ = Sub64(a3, n3, b)
+	}
+
+	if (n3 | n2 | n1 | (n0 ^ 1)) != 0 { // gcd(z,m) != 1
+		return 0
+	}
+
+	return 1 - 2 * int(s & 1)
+}
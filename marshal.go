@@ -0,0 +1,142 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Encodings of residues include the modulus, so that decoding can verify or select the modulus.
+//
+// The binary encoding is the 32-byte big-endian modulus followed by the 32-byte big-endian
+// canonical representative of the residue class.
+// The text encoding is the same as the output of String, e.g. "0x2a (mod 0xffffffff00000001...)".
+// The JSON encoding is the text encoding as a JSON string.
+//
+// When decoding into a residue that already has a modulus, the encoded modulus must be the same.
+// Otherwise the residue gets the modulus registered with RegisterModulus, or a new modulus object.
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (z *Residue) MarshalBinary() ([]byte, error) {
+	if z.m == nil {
+		return nil, ErrUninitialized
+	}
+
+	b := make([]byte, 64)
+
+	z.m.FillBytes(b[:32])
+	z.FillBytes(b[32:])
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The residue value must be canonical.
+func (z *Residue) UnmarshalBinary(data []byte) error {
+	var m, x [32]byte
+
+	if len(data) != 64 {
+		return errors.New("Invalid residue encoding")
+	}
+
+	copy(m[:], data[:32])
+	copy(x[:], data[32:])
+
+	mod, err := z.bindModulus(fromBytes32BE(&m))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = z.SetCanonicalBytes32BE(mod, x)
+
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (z *Residue) MarshalText() ([]byte, error) {
+	if z.m == nil {
+		return nil, ErrUninitialized
+	}
+
+	return []byte(z.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// The modulus may be left out, e.g. "0x2a", when decoding into a residue that already has a modulus.
+// Numbers may be given in any base supported by (*big.Int).SetString with base 0,
+// and the residue value is reduced if necessary.
+func (z *Residue) UnmarshalText(text []byte) error {
+	var x, m big.Int
+
+	s := strings.TrimSpace(string(text))
+	v := s
+
+	if i := strings.Index(s, "(mod "); i >= 0 && strings.HasSuffix(s, ")") {
+		v = strings.TrimSpace(s[:i])
+
+		if _, ok := m.SetString(strings.TrimSpace(s[i+5:len(s)-1]), 0); !ok {
+			return errors.New("Invalid modulus string")
+		}
+	} else if z.m != nil {
+		toBig(&m, z.m.m)
+	} else {
+		return errors.New("Missing modulus")
+	}
+
+	if _, ok := x.SetString(v, 0); !ok {
+		return errors.New("Invalid residue string")
+	}
+
+	if m.Sign() < 0 {
+		return ErrModulusTooSmall
+	}
+
+	if m.BitLen() > 256 {
+		return ErrModulusTooLarge
+	}
+
+	w := m.Bits()
+
+	mod, err := z.bindModulus([4]uint64{ limb(w, 0), limb(w, 1), limb(w, 2), limb(w, 3) })
+
+	if err != nil {
+		return err
+	}
+
+	z.FromBig(mod, &x)
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (z *Residue) MarshalJSON() ([]byte, error) {
+	text, err := z.MarshalText()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// The JSON value must be a string holding the text encoding, or null, which leaves z unchanged.
+func (z *Residue) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return z.UnmarshalText([]byte(s))
+}
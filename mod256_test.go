@@ -6,6 +6,8 @@ package mod256
 
 import (
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/bits"
@@ -218,6 +220,142 @@ func TestMmu1Fail(t *testing.T) {
 	}
 }
 
+func TestModulusConstants(t *testing.T) {
+	var count int
+
+	test_mod := test_all
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		x, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		if err := x.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+
+		c := x.Constants()
+
+		y, err := NewModulusFromConstants(c, true)
+
+		if err != nil || y.Constants() != c {
+			t.Fatalf("NewModulusFromConstants() failed: %v", err)
+		}
+
+		b, err := x.MarshalBinary()
+
+		if err != nil {
+			t.Fatalf("MarshalBinary() failed")
+		}
+
+		var z Modulus
+
+		if err := z.UnmarshalBinary(b); err != nil || z.Constants() != c {
+			t.Fatalf("UnmarshalBinary() failed: %v", err)
+		}
+
+		// Any change to a derived value must be detected
+
+		for i:=0; i<13; i++ {
+			for _, d := range []uint64{ 1, ^uint64(0), 1 << 63 } {
+				e := c
+
+				switch {
+				case i < 5:
+					e.Mu[i] += d
+				case i < 9:
+					e.Mmu0[i-5] += d
+				default:
+					e.Mmu1[i-9] += d
+				}
+
+				if _, err := NewModulusFromConstants(e, true); err == nil {
+					t.Fatalf("NewModulusFromConstants() did not fail: %v %v", i, d)
+				}
+
+				if _, err := NewModulusFromConstants(e, false); err != nil {
+					t.Fatalf("NewModulusFromConstants() without validation failed")
+				}
+
+				count++
+			}
+		}
+	}
+
+	c := ModulusConstants{ M: [4]uint64{ 1, 2, 3, 0 } }
+
+	if _, err := NewModulusFromConstants(c, false); err == nil {
+		t.Fatalf("NewModulusFromConstants() did not fail")
+	}
+
+	var z Modulus
+
+	if err := z.UnmarshalBinary(make([]byte, 17*8)); err == nil {
+		t.Fatalf("UnmarshalBinary() of zero modulus did not fail")
+	}
+
+	if err := z.UnmarshalBinary(make([]byte, 16*8)); err == nil {
+		t.Fatalf("UnmarshalBinary() of short input did not fail")
+	}
+
+	// Unmarshalling into a modulus in use must fail and leave it unchanged,
+	// and a fresh modulus must get all derived state
+
+	for _, p := range [][2]int{ { 3, 7 }, { 7, 1 }, { 1, 2 }, { 2, 3 } } {
+		var u, v, w Residue
+
+		x, _ := NewModulusFromString(testPrimes[p[0]], 16)
+		y, _ := NewModulusFromString(testPrimes[p[1]], 16)
+
+		c := x.Constants()
+		r := x.red
+
+		b, _ := y.MarshalBinary()
+
+		if err := x.UnmarshalBinary(b); err == nil || x.Constants() != c || x.red != r {
+			t.Fatalf("UnmarshalBinary() of %v into %v did not fail", testPrimes[p[1]], testPrimes[p[0]])
+		}
+
+		var z Modulus
+
+		if err := z.UnmarshalBinary(b); err != nil || z.red != y.red || z.k != y.k {
+			t.Fatalf("UnmarshalBinary() of %v lost the special reduction", testPrimes[p[1]])
+		}
+
+		u.FromUint64(&z, [4]uint64{ 257, 479, 487, 491 })
+		v.FromUint64(y, [4]uint64{ 257, 479, 487, 491 })
+
+		u.Square()
+		v.Square()
+
+		w.Copy(&u)
+
+		if u.ToUint64() != v.ToUint64() || !w.Sqrt() || w.Square().NotEqual(&u) {
+			t.Fatalf("Arithmetic after UnmarshalBinary() of %v failed", testPrimes[p[1]])
+		}
+
+		count++
+	}
+
+	x, _ := NewModulusFromUint64(nistp256)
+	s := fmt.Sprintf("%#v", x.Constants())
+
+	e := "mod256.ModulusConstants{M:[4]uint64{0xffffffffffffffff, 0xffffffff, 0x0, 0xffffffff00000001}, Mu:"
+
+	if len(s) < len(e) || s[:len(e)] != e {
+		t.Fatalf("%v", s)
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
 func testResidueFromUint64_OK(t *testing.T) {
 	var r Residue
 
@@ -388,137 +526,158 @@ func TestResidueCompatibility(t *testing.T) {
 	t.Logf("%v tests\n", count)
 }
 
-func TestResidueFromToUint64(t *testing.T) {
+func TestErrors(t *testing.T) {
 	var (
-		r           Residue
-		bm, b, bmod big.Int
-		count       int
+		r1, r2, r3, u Residue
+		nomod         Residue
+		count         int
 	)
 
-	test_mod := test_all
-	test_ops := test_all
+	test_mod := test_fixed
+	test_ops := test_random[:4]
+
+	m0, _ := NewModulusFromUint64(nistp256)
+	r3.FromUint64(m0, [4]uint64{ 1, 2, 3, 4 })
 
 	for _, m := range test_mod {
+		mod, err := NewModulusFromUint64(m)
 
 		if m[3] == 0 {
+			if !errors.Is(err, ErrModulusTooSmall) {
+				t.Fatalf("NewModulusFromUint64(): %v", err)
+			}
 			continue
 		}
 
-		mod, err := NewModulusFromUint64(m)
+		for _, a := range test_ops {
+			r1.FromUint64(mod, a)
 
-		if err != nil {
-			t.Fatalf("NewModulusFromUint64() failed")
-		}
+			if _, err := r2.FromUint64E(mod, a); err != nil {
+				t.Fatalf("FromUint64E() failed: %v", err)
+			}
 
-		bm.SetString(fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0]), 16)
+			for _, f := range []func(x *Residue) (*Residue, error){ u.AddE, u.SubE, u.MulE } {
+				u.Copy(&r1)
 
-		for _, a := range test_ops {
-			r.FromUint64(mod, a).ToUint64()
+				if _, err := f(&r2); err != nil {
+					t.Fatalf("%v", err)
+				}
 
-			b.SetString(fmt.Sprintf("%016x%016x%016x%016x", a[3], a[2], a[1], a[0]), 16)
-			bmod.Mod(&b, &bm)
+				if m == nistp256 {
+					continue
+				}
 
-			b.SetString(fmt.Sprintf("%016x%016x%016x%016x", r.r[3], r.r[2], r.r[1], r.r[0]), 16)
+				if _, err := f(&r3); !errors.Is(err, ErrIncompatibleModuli) {
+					t.Fatalf("Incompatible moduli not detected: %v", err)
+				}
 
-			if bmod.Cmp(&b) != 0 {
-				t.Fatalf("%v != %v", bmod, b)
+				if _, err := f(&nomod); !errors.Is(err, ErrUninitialized) {
+					t.Fatalf("Uninitialized residue not detected: %v", err)
+				}
+
+				count += 3
 			}
 
-			count++
+			if u.Copy(&r1).Add(&r2).NotEqual(r2.Copy(&r1).Double()) {
+				t.Fatalf("AddE() and Add() differ")
+			}
 		}
 	}
-	t.Logf("%v tests\n", count)
-}
 
-func TestReciprocal(t *testing.T) {
-	check := func(m [4]uint64, e, mu [5]uint64) {
-		if mu != e {
-			t.Errorf("Computed 2**512//0x%016x%016x%016x%016x\n", m[3], m[2], m[1], m[0])
-			t.Errorf("expected 0x%016x%016x%016x%016x%016x\n", e[4], e[3], e[2], e[1], e[0])
-			t.Fatalf("received 0x%016x%016x%016x%016x%016x\n", mu[4], mu[3], mu[2], mu[1], mu[0])
-		}
+	if _, err := nomod.FromUint64E(nil, [4]uint64{ 1, 2, 3, 4 }); !errors.Is(err, ErrUninitialized) {
+		t.Fatalf("FromUint64E(nil): %v", err)
 	}
 
-	var (
-		count                              int
-		str_x, str_m, str_mu               string
-		big_1, big_x, big_m, big_mu, big_e big.Int
-		m                                  [4]uint64
-		e, mu                              [5]uint64
-	)
+	if _, err := nomod.FromUint64E(&Modulus{}, [4]uint64{ 1, 2, 3, 4 }); !errors.Is(err, ErrModulusTooSmall) {
+		t.Fatalf("FromUint64E(0): %v", err)
+	}
 
-	m = [4]uint64{0x0000000000000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000001}
-	e = [5]uint64{0, 0, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
-	mu = reciprocal(m)
+	// Panic values are the same errors
 
-	check(m, e, mu)
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrIncompatibleModuli) {
+			t.Fatalf("Unexpected panic value: %v", err)
+		}
+	}()
 
-	m = [4]uint64{0x0000000000000000, 0x0000000000000000, 0xffffffffffffffff, 0xffffffffffffffff}
-	e = [5]uint64{1, 0, 1, 0, 1}
-	mu = reciprocal(m)
+	r1.FromUint64(m0, [4]uint64{ 1, 2, 3, 4 })
+	r2.FromUint64(r2.m, [4]uint64{ 1, 2, 3, 4 })
 
-	check(m, e, mu)
+	t.Logf("%v tests\n", count)
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffe, 0xffffffffffffffff}
+	r1.Mul(&r2)
+}
 
-	for i:=uint64(2); i<134217728; i++ {
-		e = [5]uint64{i, 0, 1, 0, 1}
-		mu = reciprocal(m)
+func TestSetters(t *testing.T) {
+	var (
+		r           Residue
+		bm, b, bmod big.Int
+		count       int
+	)
 
-		check(m, e, mu)
-		m[0]--
+	test_mod := test_all
 
-		count++
-	}
+	values := []int64{ 0, 1, -1, 2, -2, 1 << 62, -1 << 63, 1<<63 - 1 }
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffd, 0xffffffffffffffff}
-	e = [5]uint64{5, 0, 2, 0, 1}
-	mu = reciprocal(m)
+	for _, m := range test_mod {
 
-	check(m, e, mu)
-	count++
+		if m[3] == 0 {
+			continue
+		}
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffc, 0xffffffffffffffff}
-	e = [5]uint64{0xa, 0, 3, 0, 1}
-	mu = reciprocal(m)
+		mod, err := NewModulusFromUint64(m)
 
-	check(m, e, mu)
-	count++
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffb, 0xffffffffffffffff}
-	e = [5]uint64{0x11, 0, 4, 0, 1}
-	mu = reciprocal(m)
+		toBig(&bm, m)
 
-	check(m, e, mu)
-	count++
+		for _, v := range values {
+			bmod.Mod(b.SetInt64(v), &bm)
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffff7, 0xffffffffffffffff}
-	e = [5]uint64{0x41, 0, 8, 0, 1}
-	mu = reciprocal(m)
+			if r.SetInt64(mod, v).ToBig(&b).Cmp(&bmod) != 0 {
+				t.Fatalf("SetInt64(%v): %v != %v", v, &b, &bmod)
+			}
 
-	check(m, e, mu)
-	count++
+			if r.IsZero() != (v == 0) || r.IsOne() != (v == 1) {
+				t.Fatalf("IsZero()/IsOne() failed for %v", v)
+			}
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffef, 0xffffffffffffffff}
-	e = [5]uint64{0x101, 0, 0x10, 0, 1}
-	mu = reciprocal(m)
+			if v >= 0 && r.SetUint64(mod, uint64(v)).ToBig(&b).Cmp(&bmod) != 0 {
+				t.Fatalf("SetUint64(%v): %v != %v", v, &b, &bmod)
+			}
 
-	check(m, e, mu)
-	count++
+			count++
+		}
 
-	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffff7f, 0xffffffffffffffff}
-	e = [5]uint64{0x4001, 0, 0x80, 0, 1}
-	mu = reciprocal(m)
+		if !r.SetZero(mod).IsZero() || !r.SetOne(mod).IsOne() || r.IsZero() {
+			t.Fatalf("SetZero()/SetOne() failed")
+		}
 
-	check(m, e, mu)
-	count++
+		// m and m+1 are non-canonical representatives of 0 and 1
 
-	test_mod := test_all
+		if !r.FromUint64(mod, m).IsZero() {
+			t.Fatalf("IsZero(m) failed")
+		}
 
-	big_1.SetUint64(1)
+		if m[0] != ^uint64(0) && !r.FromUint64(mod, [4]uint64{ m[0]+1, m[1], m[2], m[3] }).IsOne() {
+			t.Fatalf("IsOne(m+1) failed")
+		}
+	}
 
-	str_x = fmt.Sprintf("%016x%016x%016x%016x%016x%016x%016x%016x%016x", 1, 0, 0, 0, 0, 0, 0, 0, 0)
-	big_x.SetString(str_x,  16)
+	t.Logf("%v tests\n", count)
+}
+
+func TestResidueFromToUint64(t *testing.T) {
+	var (
+		r           Residue
+		bm, b, bmod big.Int
+		count       int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
 
 	for _, m := range test_mod {
 
@@ -526,155 +685,1498 @@ func TestReciprocal(t *testing.T) {
 			continue
 		}
 
-		mu = reciprocal(m)
+		mod, err := NewModulusFromUint64(m)
 
-		str_m  = fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0])
-		str_mu = fmt.Sprintf("%016x%016x%016x%016x%016x", mu[4], mu[3], mu[2], mu[1], mu[0])
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
 
-		big_m.SetString(str_m,  16)
-		big_mu.SetString(str_mu, 16)
+		bm.SetString(fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0]), 16)
 
-		big_e.Div(&big_x, &big_m)
+		for _, a := range test_ops {
+			r.FromUint64(mod, a).ToUint64()
 
-		if m[0] | m[1] | m[2] | (m[3] & (m[3]-1)) == 0 {
-			// m is a power of 2
-			// mu is then one less than 2^512/m
-			big_e.Sub(&big_e, &big_1)
-		}
+			b.SetString(fmt.Sprintf("%016x%016x%016x%016x", a[3], a[2], a[1], a[0]), 16)
+			bmod.Mod(&b, &bm)
 
-		if big_e.Cmp(&big_mu) != 0 {
-			t.Errorf("Computed 2**512 // %v\n", str_m)
-			t.Errorf("expected %v\n", big_e.Text(16))
-			t.Fatalf("received %v\n", big_mu.Text(16))
+			b.SetString(fmt.Sprintf("%016x%016x%016x%016x", r.r[3], r.r[2], r.r[1], r.r[0]), 16)
+
+			if bmod.Cmp(&b) != 0 {
+				t.Fatalf("%v != %v", bmod, b)
+			}
+
+			count++
 		}
-		count++
 	}
-
 	t.Logf("%v tests\n", count)
 }
 
-func TestEqNeq(t *testing.T) {
-	var (
-		r             [4]Residue
-		a, b, c, x, y Residue
-		u, v          bool
-		count         int
-	)
+func TestModulusFromToBig(t *testing.T) {
+	var bm big.Int
 
-	// All unequal
+	test_mod := test_all
+
+	for _, m := range test_mod {
+
+		bm.SetString(fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0]), 16)
+
+		x, err := NewModulusFromBig(&bm)
+
+		if m[3] == 0 {
+			if err == nil {
+				t.Fatalf("NewModulusFromBig() did not fail")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("NewModulusFromBig() failed")
+		}
+
+		if x.ToUint64() != m || x.ToBig().Cmp(&bm) != 0 {
+			t.Fatalf("%v != %v", x.ToBig(), &bm)
+		}
+	}
+
+	bm.Lsh(big.NewInt(1), 256)
+
+	if _, err := NewModulusFromBig(&bm); err == nil {
+		t.Fatalf("NewModulusFromBig(2^256) did not fail")
+	}
+
+	bm.Neg(&bm)
+
+	if _, err := NewModulusFromBig(&bm); err == nil {
+		t.Fatalf("NewModulusFromBig(-2^256) did not fail")
+	}
+}
+
+func TestResidueFromToBig(t *testing.T) {
+	var (
+		r           Residue
+		bm, b, bmod big.Int
+		res         big.Int
+		count       int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_random
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		bm.SetString(fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0]), 16)
+
+		for i, a := range test_ops {
+
+			// Operands of 0 to 1280 bits, positive and negative
+
+			b.SetInt64(0)
+
+			for j:=0; j<i%6; j++ {
+				b.Lsh(&b, 256)
+				res.SetString(fmt.Sprintf("%016x%016x%016x%016x", a[3], a[2], a[1], a[0]), 16)
+				b.Add(&b, &res)
+			}
+
+			if i % 2 == 1 {
+				b.Neg(&b)
+			}
+
+			bmod.Mod(&b, &bm)
+
+			r.FromBig(mod, &b).ToBig(&res)
+
+			if bmod.Cmp(&res) != 0 {
+				t.Fatalf("%v mod %v: %v != %v", &b, &bm, &bmod, &res)
+			}
+
+			count++
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r.ToBig(&res)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("ToBig() allocates")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestModulusBytes(t *testing.T) {
+	test_mod := test_all
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		x, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		be := x.Bytes32BE()
+		le := x.Bytes32LE()
+
+		for i:=0; i<32; i++ {
+			if be[i] != le[31-i] {
+				t.Fatalf("%x != reverse(%x)", be, le)
+			}
+		}
+
+		y, err := NewModulusFromBytes32BE(be)
+
+		if err != nil || y.ToUint64() != m {
+			t.Fatalf("NewModulusFromBytes32BE() failed")
+		}
+
+		y, err = NewModulusFromBytes32LE(le)
+
+		if err != nil || y.ToUint64() != m {
+			t.Fatalf("NewModulusFromBytes32LE() failed")
+		}
+
+		y, err = NewModulusFromBytes(append([]byte{ 0, 0 }, be[:]...))
+
+		if err != nil || y.ToUint64() != m {
+			t.Fatalf("NewModulusFromBytes() failed")
+		}
+
+		if string(x.FillBytes(make([]byte, 40))[8:]) != string(be[:]) {
+			t.Fatalf("FillBytes() failed")
+		}
+	}
+
+	if _, err := NewModulusFromBytes(append([]byte{ 1 }, make([]byte, 32)...)); err == nil {
+		t.Fatalf("NewModulusFromBytes(2^256) did not fail")
+	}
+
+	if _, err := NewModulusFromBytes([]byte{ 1, 2, 3 }); err == nil {
+		t.Fatalf("NewModulusFromBytes(0x010203) did not fail")
+	}
+}
+
+func TestResidueBytes(t *testing.T) {
+	var (
+		r, u        Residue
+		bm, b, bmod big.Int
+		buf         [100]byte
+		count       int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_random
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		mb := mod.Bytes32BE()
+		bm.SetBytes(mb[:])
+
+		for i, a := range test_ops {
+
+			// Fixed-length encodings
+
+			r.FromUint64(mod, a)
+			u.FromUint64(mod, a)
+
+			toBig(&b, a)
+			bmod.Mod(&b, &bm)
+
+			be := r.Bytes32BE()
+			le := r.Bytes32LE()
+
+			if b.SetBytes(be[:]).Cmp(&bmod) != 0 {
+				t.Fatalf("Bytes32BE(): %v != %v", &b, &bmod)
+			}
+
+			if r.SetBytes32LE(mod, le).NotEqual(&u) || r.SetBytes32BE(mod, be).NotEqual(&u) {
+				t.Fatalf("SetBytes32BE()/SetBytes32LE() failed")
+			}
+
+			if _, err := r.SetCanonicalBytes32BE(mod, be); err != nil {
+				t.Fatalf("SetCanonicalBytes32BE() failed")
+			}
+
+			if _, err := r.SetCanonicalBytes32LE(mod, le); err != nil {
+				t.Fatalf("SetCanonicalBytes32LE() failed")
+			}
+
+			// Non-canonical encoding of the same residue
+
+			var c uint64
+
+			x := r.ToUint64()
+
+			x[0], c = bits.Add64(x[0], m[0], 0)
+			x[1], c = bits.Add64(x[1], m[1], c)
+			x[2], c = bits.Add64(x[2], m[2], c)
+			x[3], c = bits.Add64(x[3], m[3], c)
+
+			if c == 0 {
+				if _, err := r.SetCanonicalBytes32BE(mod, toBytes32BE(x)); err == nil {
+					t.Fatalf("SetCanonicalBytes32BE() did not fail")
+				}
+			}
+
+			// Variable-length encodings
+
+			n := i % len(buf)
+
+			for j:=0; j<n; j++ {
+				buf[j] = byte(a[j%4] >> (8*(j%8)))
+			}
+
+			b.SetBytes(buf[:n])
+			bmod.Mod(&b, &bm)
+
+			r.SetBytes(mod, buf[:n]).ToBig(&b)
+
+			if b.Cmp(&bmod) != 0 {
+				t.Fatalf("SetBytes(): %v != %v", &b, &bmod)
+			}
+
+			for j:=0; j<n/2; j++ {
+				buf[j], buf[n-1-j] = buf[n-1-j], buf[j]
+			}
+
+			r.SetBytesLE(mod, buf[:n]).ToBig(&b)
+
+			if b.Cmp(&bmod) != 0 {
+				t.Fatalf("SetBytesLE(): %v != %v", &b, &bmod)
+			}
+
+			if b.SetBytes(r.FillBytes(buf[:40])).Cmp(&bmod) != 0 {
+				t.Fatalf("FillBytes(): %v != %v", &b, &bmod)
+			}
+
+			count++
+		}
+
+		// m-1 is canonical, m is not
+
+		mb[31]--
+
+		if _, err := r.SetCanonicalBytes32BE(mod, mb); err != nil && m[0] & 0xff != 0 {
+			t.Fatalf("SetCanonicalBytes32BE(m-1) failed")
+		}
+
+		if _, err := r.SetCanonicalBytes32BE(mod, mod.Bytes32BE()); err == nil {
+			t.Fatalf("SetCanonicalBytes32BE(m) did not fail")
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+
+	// A 1-byte buffer holds 1, but not 256
+
+	r.FromUint64(u.m, [4]uint64{ 1, 0, 0, 0 })
+
+	if r.FillBytes(buf[:1])[0] != 1 {
+		t.Fatalf("FillBytes(1) failed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("FillBytes(256) did not fail")
+		}
+	}()
+
+	r.FromUint64(u.m, [4]uint64{ 256, 0, 0, 0 }).FillBytes(buf[:1])
+}
+
+func TestText(t *testing.T) {
+	var (
+		r, u           Residue
+		bm, b, bmod, v big.Int
+		count          int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_random
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromString(fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0]), 16)
+
+		if err != nil || mod.ToUint64() != m {
+			t.Fatalf("NewModulusFromString() failed")
+		}
+
+		toBig(&bm, m)
+
+		if mod.String() != fmt.Sprintf("%#x", &bm) || fmt.Sprintf("%d", mod) != bm.String() {
+			t.Fatalf("%v != %#x", mod, &bm)
+		}
+
+		for i, a := range test_ops {
+			r.FromUint64(mod, a)
+
+			toBig(&b, a)
+			bmod.Mod(&b, &bm)
+
+			for _, f := range []string{ "%x", "%X", "%#x", "%#X", "%d", "%70d", "%-70x", "%070x" } {
+				if fmt.Sprintf(f, &r) != fmt.Sprintf(f, &bmod) {
+					t.Fatalf("%v: %v != %v", f, fmt.Sprintf(f, &r), fmt.Sprintf(f, &bmod))
+				}
+			}
+
+			if r.String() != fmt.Sprintf("%#x (mod %#x)", &bmod, &bm) || fmt.Sprintf("%v", &r) != r.String() {
+				t.Fatalf("%v != %#x (mod %#x)", &r, &bmod, &bm)
+			}
+
+			// Parsing, including negative values and values >= m
+
+			if i % 2 == 1 {
+				b.Neg(&b)
+			}
+
+			bmod.Mod(&b, &bm)
+
+			for _, base := range []int{ 0, 10, 16, 36 } {
+				str := fmt.Sprintf("%#x", &b)
+
+				if base != 0 {
+					str = b.Text(base)
+				}
+
+				if _, ok := u.SetString(mod, str, base); !ok {
+					t.Fatalf("SetString(%v, %v) failed", str, base)
+				}
+
+				if u.ToBig(&v).Cmp(&bmod) != 0 {
+					t.Fatalf("SetString(%v, %v): %v != %v", str, base, &v, &bmod)
+				}
+
+				count++
+			}
+		}
+	}
+
+	if _, ok := r.SetString(r.m, "0x12g", 0); ok {
+		t.Fatalf("SetString(0x12g) did not fail")
+	}
+
+	if _, err := NewModulusFromString("12", 10); err == nil {
+		t.Fatalf("NewModulusFromString(12) did not fail")
+	}
+
+	if _, err := NewModulusFromString("x", 10); err == nil {
+		t.Fatalf("NewModulusFromString(x) did not fail")
+	}
+
+	if fmt.Sprintf("%v", &Residue{}) != "<nil>" {
+		t.Fatalf("%v != <nil>", &Residue{})
+	}
+
+	if fmt.Sprintf("%8v|%-8s|", &Residue{}, &Residue{}) != "   <nil>|<nil>   |" {
+		t.Fatalf("Padding failed")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestMarshal(t *testing.T) {
+	var (
+		r, u, v Residue
+		count   int
+	)
+
+	type config struct {
+		X *Residue
+		Y []*Residue
+	}
+
+	test_mod := test_fixed
+	test_ops := test_random[:8]
+
+	m0, err := NewModulusFromUint64(nistp256)
+
+	if err != nil {
+		t.Fatalf("NewModulusFromUint64() failed")
+	}
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, a := range test_ops {
+			r.FromUint64(mod, a)
+
+			// Binary
+
+			b, err := r.MarshalBinary()
+
+			if err != nil {
+				t.Fatalf("MarshalBinary() failed")
+			}
+
+			u = Residue{}
+
+			if err := u.UnmarshalBinary(b); err != nil || u.NotEqual(&r) {
+				t.Fatalf("UnmarshalBinary() failed: %v", err)
+			}
+
+			v.FromUint64(m0, a)
+
+			if err := v.UnmarshalBinary(b); err == nil && m != nistp256 {
+				t.Fatalf("UnmarshalBinary() with wrong modulus did not fail")
+			}
+
+			// Text
+
+			b, err = r.MarshalText()
+
+			if err != nil {
+				t.Fatalf("MarshalText() failed")
+			}
+
+			u = Residue{}
+
+			if err := u.UnmarshalText(b); err != nil || u.NotEqual(&r) {
+				t.Fatalf("UnmarshalText(%s) failed: %v", b, err)
+			}
+
+			if err := v.UnmarshalText(b); err == nil && m != nistp256 {
+				t.Fatalf("UnmarshalText() with wrong modulus did not fail")
+			}
+
+			u.FromUint64(mod, [4]uint64{ 0, 0, 0, 0 })
+
+			if err := u.UnmarshalText([]byte(fmt.Sprintf("%#x", &r))); err != nil || u.NotEqual(&r) {
+				t.Fatalf("UnmarshalText(%#x) failed: %v", &r, err)
+			}
+
+			// JSON
+
+			b, err = json.Marshal(config{ X: &r, Y: []*Residue{ &r, &r } })
+
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %v", err)
+			}
+
+			var c config
+
+			if err := json.Unmarshal(b, &c); err != nil || c.X.NotEqual(&r) || c.Y[1].NotEqual(&r) {
+				t.Fatalf("json.Unmarshal(%s) failed: %v", b, err)
+			}
+
+			count += 3
+		}
+	}
+
+	// Registered moduli are shared
+
+	RegisterModulus(m0)
+
+	r.FromUint64(m0, [4]uint64{ 1, 2, 3, 4 })
+	b, _ := r.MarshalBinary()
+
+	u = Residue{}
+
+	if err := u.UnmarshalBinary(b); err != nil || u.m != m0 || LookupModulus(nistp256) != m0 {
+		t.Fatalf("Registered modulus not used")
+	}
+
+	// Invalid encodings
+
+	b[63]++
+
+	if err := u.UnmarshalBinary(b[:63]); err == nil {
+		t.Fatalf("UnmarshalBinary() of short input did not fail")
+	}
+
+	copy(b[32:], b[:32])
+
+	if err := u.UnmarshalBinary(b); err == nil {
+		t.Fatalf("UnmarshalBinary() of non-canonical residue did not fail")
+	}
+
+	u = Residue{}
+
+	for _, s := range []string{ "0x2a", "0x2a (mod 0x10)", "0x2a (mod x)", "x (mod 0x2a)", "0x2a (mod -0x1000000000000000000000000000000000000000000000000)" } {
+		if err := u.UnmarshalText([]byte(s)); err == nil {
+			t.Fatalf("UnmarshalText(%v) did not fail", s)
+		}
+	}
+
+	if _, err := u.MarshalBinary(); err == nil {
+		t.Fatalf("MarshalBinary() of uninitialized residue did not fail")
+	}
+
+	if err := u.UnmarshalJSON([]byte("null")); err != nil || u.m != nil {
+		t.Fatalf("UnmarshalJSON(null) failed")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestReciprocal(t *testing.T) {
+	check := func(m [4]uint64, e, mu [5]uint64) {
+		if mu != e {
+			t.Errorf("Computed 2**512//0x%016x%016x%016x%016x\n", m[3], m[2], m[1], m[0])
+			t.Errorf("expected 0x%016x%016x%016x%016x%016x\n", e[4], e[3], e[2], e[1], e[0])
+			t.Fatalf("received 0x%016x%016x%016x%016x%016x\n", mu[4], mu[3], mu[2], mu[1], mu[0])
+		}
+	}
+
+	var (
+		count                              int
+		str_x, str_m, str_mu               string
+		big_1, big_x, big_m, big_mu, big_e big.Int
+		m                                  [4]uint64
+		e, mu                              [5]uint64
+	)
+
+	m = [4]uint64{0x0000000000000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000001}
+	e = [5]uint64{0, 0, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+
+	m = [4]uint64{0x0000000000000000, 0x0000000000000000, 0xffffffffffffffff, 0xffffffffffffffff}
+	e = [5]uint64{1, 0, 1, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffe, 0xffffffffffffffff}
+
+	for i:=uint64(2); i<134217728; i++ {
+		e = [5]uint64{i, 0, 1, 0, 1}
+		mu = reciprocal(m)
+
+		check(m, e, mu)
+		m[0]--
+
+		count++
+	}
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffd, 0xffffffffffffffff}
+	e = [5]uint64{5, 0, 2, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffc, 0xffffffffffffffff}
+	e = [5]uint64{0xa, 0, 3, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffffb, 0xffffffffffffffff}
+	e = [5]uint64{0x11, 0, 4, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xfffffffffffffff7, 0xffffffffffffffff}
+	e = [5]uint64{0x41, 0, 8, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffef, 0xffffffffffffffff}
+	e = [5]uint64{0x101, 0, 0x10, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	m = [4]uint64{0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffff7f, 0xffffffffffffffff}
+	e = [5]uint64{0x4001, 0, 0x80, 0, 1}
+	mu = reciprocal(m)
+
+	check(m, e, mu)
+	count++
+
+	test_mod := test_all
+
+	big_1.SetUint64(1)
+
+	str_x = fmt.Sprintf("%016x%016x%016x%016x%016x%016x%016x%016x%016x", 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	big_x.SetString(str_x,  16)
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mu = reciprocal(m)
+
+		str_m  = fmt.Sprintf("%016x%016x%016x%016x", m[3], m[2], m[1], m[0])
+		str_mu = fmt.Sprintf("%016x%016x%016x%016x%016x", mu[4], mu[3], mu[2], mu[1], mu[0])
+
+		big_m.SetString(str_m,  16)
+		big_mu.SetString(str_mu, 16)
+
+		big_e.Div(&big_x, &big_m)
+
+		if m[0] | m[1] | m[2] | (m[3] & (m[3]-1)) == 0 {
+			// m is a power of 2
+			// mu is then one less than 2^512/m
+			big_e.Sub(&big_e, &big_1)
+		}
+
+		if big_e.Cmp(&big_mu) != 0 {
+			t.Errorf("Computed 2**512 // %v\n", str_m)
+			t.Errorf("expected %v\n", big_e.Text(16))
+			t.Fatalf("received %v\n", big_mu.Text(16))
+		}
+		count++
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestEqNeq(t *testing.T) {
+	var (
+		r             [4]Residue
+		a, b, c, x, y Residue
+		u, v          bool
+		count         int
+	)
+
+	// All unequal
 
 	m1, err := NewModulusFromUint64([4]uint64 { 1, 2, 3, 4 })
 
-	if err != nil {
-		t.Fatalf("NewModulusFromUint64() failed")
+	if err != nil {
+		t.Fatalf("NewModulusFromUint64() failed")
+	}
+
+	m2, err := NewModulusFromUint64([4]uint64 { 5, 6, 7, 8 })
+
+	if err != nil {
+		t.Fatalf("NewModulusFromUint64() failed")
+	}
+
+	r[0].FromUint64(m1, [4]uint64 { 1, 3, 5, 7 })
+	r[1].FromUint64(m1, [4]uint64 { 2, 4, 6, 8 })
+
+	r[2].FromUint64(m2, [4]uint64 { 1, 3, 5, 7 })
+	r[3].FromUint64(m2, [4]uint64 { 2, 4, 6, 8 })
+
+	for i:=0; i<4; i++ {
+		for j:=0; j<4; j++ {
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if (i == j) && !x.Equal(&y) {
+				t.Fatalf("Equal(%v, %v)", r[i], r[j])
+			}
+
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if (i != j) && x.Equal(&y) {
+				t.Fatalf("Equal(%v, %v)", r[i], r[j])
+			}
+
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if (i == j) && x.NotEqual(&y) {
+				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+			}
+
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if (i != j) && !x.NotEqual(&y) {
+				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+			}
+			count+=2
+		}
+	}
+
+	// All equal
+
+	r[0].FromUint64(m1, [4]uint64 { 1, 3,  5,  7 })
+	r[1].FromUint64(m1, [4]uint64 { 2, 5,  8, 11 })
+	r[2].FromUint64(m1, [4]uint64 { 3, 7, 11, 15 })
+	r[3].FromUint64(m1, [4]uint64 { 4, 9, 14, 19 })
+
+	for i:=0; i<4; i++ {
+		for j:=0; j<4; j++ {
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if !x.Equal(&y) {
+				t.Fatalf("Equal(%v, %v)", r[i], r[j])
+			}
+
+			x.Copy(&r[i])
+			y.Copy(&r[j])
+
+			if x.NotEqual(&y) {
+				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+			}
+			count+=2
+		}
+	}
+
+	test_mod := test_all
+	test_ops := test_fixed
+
+	// a == a+m (mod m)
+	// Equal(a,b) != NotEqual(a,b) for all a,b
+
+	for i, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		m1, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for j, _a := range test_ops {
+			a.FromUint64(m1, _a)
+
+			for k, _b := range test_ops {
+				b.FromUint64(m1, _b)
+				c.Copy(&a)
+
+				u = c.Equal(&b)
+				v = c.NotEqual(&b)
+
+				if u == v {
+					t.Errorf("a==b = %v", u)
+					t.Errorf("a!=b = %v", v)
+					t.Errorf("(%v,%v,%v)\n", i, j, k);
+					t.Fatalf("%v\n%v\n%v\n", a, b, m);
+				}
+
+				count++
+			}
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestReflexivity(t *testing.T) {
+	var (
+		a     Residue
+		u     bool
+		count int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a == a for all a
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			u = a.Equal(&a)
+
+			if u == false {
+				t.Errorf("a=%v\n", a);
+				t.Errorf("m=%v\n", m);
+				t.Fatalf("a!=a (mod %v)\n", m);
+			}
+
+			count++
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestSymmetry(t *testing.T) {
+	var (
+		a, b  Residue
+		u, v  bool
+		count int
+	)
+
+	test_mod := test_all
+	test_ops := test_random
+
+	for i, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for j, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			for k, _b := range test_ops {
+				b.FromUint64(mod, _b)
+
+				// (a==b) == (b==a)
+
+				u = a.Equal(&b)
+				v = b.Equal(&a)
+
+				if u != v {
+					t.Errorf("a==b = %v", u)
+					t.Errorf("b==a = %v", v)
+					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
+				}
+
+				count++
+
+				// (a!=b) == (b!=a)
+
+				u = a.NotEqual(&b)
+				v = b.NotEqual(&a)
+
+				if u != v {
+					t.Errorf("a!=b = %v", u)
+					t.Errorf("b!=a = %v", v)
+					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
+				}
+
+				count++
+			}
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestTransitivity(t *testing.T) {
+	t.Log("Not implemented")
+}
+
+func TestAdditiveIdentity(t *testing.T) {
+	var (
+		zero, zero_m, zero_p, a, u, v, w, x, y, z Residue
+		count                                     int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a+0 == 0+a == a
+
+	for _, m := range test_mod {
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		zero_p.FromUint64(mod, m)	// residue value m == 0 (mod m)
+		zero.Copy(&zero_p).Sub(&zero_p)	// 0
+		zero_m.Copy(&zero).Sub(&zero_p)	// 0-m
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			u.Copy(&a).Add(&zero_m)
+			v.Copy(&a).Add(&zero  )
+			w.Copy(&a).Add(&zero_p)
+
+			x.Copy(&zero_m).Add(&a)
+			y.Copy(&zero  ).Add(&a)
+			z.Copy(&zero_p).Add(&a)
+
+			if a.NotEqual(&u) || a.NotEqual(&v) || a.NotEqual(&w) || a.NotEqual(&x) || a.NotEqual(&y) || a.NotEqual(&z) {
+				t.Errorf("m=%v\n", m);
+				t.Errorf("a=%v\n", a);
+				t.Errorf("u=%v\n", u);
+				t.Errorf("v=%v\n", v);
+				t.Errorf("w=%v\n", w);
+				t.Errorf("x=%v\n", x);
+				t.Errorf("y=%v\n", y);
+				t.Fatalf("z=%v\n", z);
+			}
+
+			count++
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestMultiplicativeIdentity(t *testing.T) {
+	var (
+		zero, one, one_m, one_p, a, u, v, w, x, y, z Residue
+		count                                        int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a*1 == 1*a == a
+
+	for _, m := range test_mod {
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		zero.FromUint64(mod, m)	// residue value m == 0 (mod m)
+		one.FromUint64(mod, [4]uint64{ 1, 0, 0, 0 })
+
+		one_m.Copy(&one).Sub(&zero)	// 1-m
+		one_p.Copy(&one).Add(&zero)	// 1+m
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			u.Copy(&a).Mul(&one_m)
+			v.Copy(&a).Mul(&one  )
+			w.Copy(&a).Mul(&one_p)
+
+			x.Copy(&one_m).Mul(&a)
+			y.Copy(&one  ).Mul(&a)
+			z.Copy(&one_p).Mul(&a)
+
+			if a.NotEqual(&u) || a.NotEqual(&v) || a.NotEqual(&w) || a.NotEqual(&x) || a.NotEqual(&y) || a.NotEqual(&z) {
+				t.Errorf("m=%v\n", m);
+				t.Errorf("a=%v\n", a);
+				t.Errorf("u=%v\n", u);
+				t.Errorf("v=%v\n", v);
+				t.Errorf("w=%v\n", w);
+				t.Errorf("x=%v\n", x);
+				t.Errorf("y=%v\n", y);
+				t.Fatalf("z=%v\n", z);
+			}
+
+			count++
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestAdditiveInverse(t *testing.T) {
+	var (
+		a, b, u, v, w Residue
+		count         int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a+(-a) == (-a)+a == 0
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			v.Copy(&a).Neg()
+
+			// a+(-a)
+			u.Copy(&a).Add(&v)
+
+			// (-a)+a
+			v.Add(&a)
+
+			if u.NotEqual(&v) {
+				t.Errorf("a=%v\n", a);
+				t.Errorf("m=%v\n", m);
+				t.Errorf("a+(-a)=%v\n", u);
+				t.Fatalf("(-a)+a=%v\n", v);
+			}
+
+			count++
+		}
+	}
+
+	// a == -(-a))
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			u.Copy(&a).Neg().Neg()
+
+			if u.NotEqual(&a) {
+				t.Errorf("a=%v\n", a);
+				t.Errorf("m=%v\n", m);
+				t.Fatalf("-(-a)=%v\n", u);
+			}
+
+			count++
+		}
 	}
 
-	m2, err := NewModulusFromUint64([4]uint64 { 5, 6, 7, 8 })
+	test_mod = test_all
+	test_ops = test_random
 
-	if err != nil {
-		t.Fatalf("NewModulusFromUint64() failed")
+	// a-b == (-b)+a == -(b-a)
+
+	for i, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for j, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			for k, _b := range test_ops {
+				b.FromUint64(mod, _b)
+
+				// a-b
+				u.Copy(&a).Sub(&b)
+
+				// (-b)+a
+				v.Copy(&b).Neg().Add(&a)
+
+				// -(b-a)
+				w.Copy(&b).Sub(&a).Neg()
+
+				if u.NotEqual(&v) || u.NotEqual(&w) || v.NotEqual(&w) {
+					t.Errorf("a-b = %v", u)
+					t.Errorf("-b+a = %v", v)
+					t.Errorf("-(b-a) = %v", w)
+					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
+				}
+
+				count++
+			}
+		}
 	}
 
-	r[0].FromUint64(m1, [4]uint64 { 1, 3, 5, 7 })
-	r[1].FromUint64(m1, [4]uint64 { 2, 4, 6, 8 })
+	t.Logf("%v tests\n", count)
+}
 
-	r[2].FromUint64(m2, [4]uint64 { 1, 3, 5, 7 })
-	r[3].FromUint64(m2, [4]uint64 { 2, 4, 6, 8 })
+func TestMultiplicativeInverse(t *testing.T) {
 
-	for i:=0; i<4; i++ {
-		for j:=0; j<4; j++ {
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+	var (
+		a, u, v, one              Residue
+		invertible, noninvertible int
+	)
 
-			if (i == j) && !x.Equal(&y) {
-				t.Fatalf("Equal(%v, %v)", r[i], r[j])
+	test_mod := test_fixed
+	test_ops := test_fixed
+
+	// if 1/a exists, then
+	//	a*(1/a) == (1/a)*a == 1
+
+	for i, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		one.FromUint64(mod, [4]uint64{ 1, 0, 0, 0 })
+
+		for j, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			// 1/a
+			u.Copy(&a)
+			if !u.Inv() {
+				noninvertible++
+				continue
 			}
 
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+			// a * 1/a
+			v.Copy(&a)
+			v.Mul(&u)
 
-			if (i != j) && x.Equal(&y) {
-				t.Fatalf("Equal(%v, %v)", r[i], r[j])
+			if v.NotEqual(&one) {
+				t.Errorf("%v,%v\n", i, j);
+				t.Fatalf("%v^2%%%v\n%v\n%v\n", a, m, u, v);
 			}
 
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+			invertible++
+		}
+	}
+	t.Logf("%v invertible, %v noninvertible\n", invertible, noninvertible)
+}
 
-			if (i == j) && x.NotEqual(&y) {
-				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+// Inv supports even moduli, with the inverse existing exactly for odd residues coprime to m
+func TestInvEvenModulus(t *testing.T) {
+	var (
+		a, u, v, w Residue
+		ba, bm, bi big.Int
+		count      int
+	)
+
+	moduli := []string{
+		"8000000000000100000000000000000000000000000000000000000000000000", // 2^255 + 2^200
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe", // 2^256 - 2
+		"ffffffff00000001000000000000000000000000fffffffffffffffffffffffe", // P-256 + 1
+		"3000000000000000000000000000000000000000000000000",                // 3 * 2^192
+		"1000000000000000000000000000000000000000000000000",                // 2^192
+		"30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd46", // BN254 - 1
+	}
+
+	// Fixed values, and random values combined in pairs
+
+	test_ops := append([][4]uint64{}, test_fixed...)
+
+	for _, _a := range test_random {
+		for _, _b := range test_random[:8] {
+			test_ops = append(test_ops, [4]uint64{ _a[0] ^ _b[0], _a[1] ^ _b[1], _a[2] ^ _b[2], _a[3] ^ _b[3] })
+		}
+	}
+
+	for _, ms := range moduli {
+		mod, err := NewModulusFromString(ms, 16)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromString() failed")
+		}
+
+		bm.SetString(ms, 16)
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+			toBig(&ba, _a)
+
+			ok := u.Copy(&a).Inv()
+			exists := bi.ModInverse(&ba, &bm) != nil
+
+			if ok != exists || (ok && u.ToBig(new(big.Int)).Cmp(&bi) != 0) {
+				t.Fatalf("Inv(%v) mod %v = %v, %v, expected %x, %v", &a, ms, &u, ok, &bi, exists)
 			}
 
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+			if ok && v.Product(&a, &u).NotEqual(w.SetOne(mod)) {
+				t.Fatalf("%v * Inv(%v) = %v", &a, &a, &v)
+			}
 
-			if (i != j) && !x.NotEqual(&y) {
-				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+			count++
+		}
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestExtendedGCD(t *testing.T) {
+	var (
+		a, u, v                Residue
+		ba, bm, bg, bs, bt, bx big.Int
+		count                  int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_all
+
+	for i, m := range test_mod {
+
+		if i % 7 != 0 {
+			continue
+		}
+
+		toBig(&bm, m)
+
+		for _, _a := range test_ops {
+			toBig(&ba, _a)
+
+			g := ExtendedGCD(&bs, &bt, _a, m)
+
+			// g = gcd(a, m) = a*s + m*t
+
+			bg.GCD(nil, nil, &ba, &bm)
+			bx.Mul(&ba, &bs)
+			bx.Add(&bx, new(big.Int).Mul(&bt, &bm))
+
+			if toBig(new(big.Int), g).Cmp(&bg) != 0 || bx.Cmp(&bg) != 0 {
+				t.Fatalf("ExtendedGCD(%x, %x) = %x, %v, %v, expected %v", _a, m, g, &bs, &bt, &bg)
 			}
-			count+=2
+
+			if ExtendedGCD(nil, nil, _a, m) != g {
+				t.Fatalf("ExtendedGCD(nil, nil, %x, %x) != %x", _a, m, g)
+			}
+
+			count++
+
+			if m[3] == 0 {
+				continue
+			}
+
+			mod, err := NewModulusFromUint64(m)
+
+			if err != nil {
+				t.Fatalf("NewModulusFromUint64() failed")
+			}
+
+			// InvOrFactor agrees with Inv, and returns gcd(a mod m, m) on failure
+
+			a.FromUint64(mod, _a)
+
+			okInv := u.Copy(&a).Inv()
+			g, ok := v.Copy(&a).InvOrFactor()
+
+			bg.GCD(nil, nil, bx.Mod(&ba, &bm), &bm)
+
+			if ok != okInv || u.NotEqual(&v) || toBig(new(big.Int), g).Cmp(&bg) != 0 {
+				t.Fatalf("InvOrFactor(%v) = %v, %x, %v, expected %v, %v, %v", &a, &v, g, ok, &u, &bg, okInv)
+			}
+
+			// Div is the same as Quotient
+
+			okDiv := u.Copy(&a).Div(&a)
+			okQuo := v.Quotient(&a, &a)
+
+			if okDiv != okQuo || u.NotEqual(&v) {
+				t.Fatalf("Div(%v, %v) = %v, %v, expected %v, %v", &a, &a, &u, okDiv, &v, okQuo)
+			}
+
+			count += 2
 		}
 	}
 
-	// All equal
+	if g := ExtendedGCD(&bs, &bt, [4]uint64{ 0, 0, 0, 0 }, [4]uint64{ 0, 0, 0, 0 }); g != [4]uint64{ 0, 0, 0, 0 } {
+		t.Fatalf("ExtendedGCD(0, 0) = %x", g)
+	}
 
-	r[0].FromUint64(m1, [4]uint64 { 1, 3,  5,  7 })
-	r[1].FromUint64(m1, [4]uint64 { 2, 5,  8, 11 })
-	r[2].FromUint64(m1, [4]uint64 { 3, 7, 11, 15 })
-	r[3].FromUint64(m1, [4]uint64 { 4, 9, 14, 19 })
+	allocs := testing.AllocsPerRun(100, func() {
+		ExtendedGCD(&bs, &bt, test_random[0], test_random[1])
+		a.InvOrFactor()
+	})
+
+	if allocs != 0 {
+		t.Fatalf("ExtendedGCD() and InvOrFactor() allocate")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestInvCT(t *testing.T) {
+	var (
+		a, u, v                   Residue
+		invertible, noninvertible int
+	)
+
+	test_mod := test_all
+	test_ops := test_fixed
+
+	// InvCT and Inv give the same results for odd moduli
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 || m[0] & 1 == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			ok1 := u.Copy(&a).Inv()
+			ok2 := v.Copy(&a).InvCT()
+
+			if ok1 != ok2 || u.NotEqual(&v) {
+				t.Fatalf("InvCT(%v) = %v, %v; Inv() = %v, %v", &a, &v, ok2, &u, ok1)
+			}
+
+			if ok1 {
+				invertible++
+			} else {
+				noninvertible++
+			}
+		}
+	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrEvenModulus) {
+			t.Fatalf("InvCT() with even modulus did not fail")
+		}
+	}()
+
+	t.Logf("%v invertible, %v noninvertible\n", invertible, noninvertible)
+
+	mod, _ := NewModulusFromUint64([4]uint64{ 2, 0, 0, 1 })
+	a.FromUint64(mod, [4]uint64{ 3, 0, 0, 0 }).InvCT()
+}
+
+func TestGeneric(t *testing.T) {
+	var (
+		p, q  [8]uint64
+		u, v  Residue
+		count int
+	)
 
-	for i:=0; i<4; i++ {
-		for j:=0; j<4; j++ {
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+	test_mod := test_fixed
+	test_ops := test_all
 
-			if !x.Equal(&y) {
-				t.Fatalf("Equal(%v, %v)", r[i], r[j])
-			}
+	// The platform-specific products and reductions match the pure Go ones.
+	// This compares the assembly with the Go code on arm64, and on amd64 with ADX.
 
-			x.Copy(&r[i])
-			y.Copy(&r[j])
+	for _, _a := range test_ops {
+		for _, _b := range test_ops {
+			mul512(&p, &_a, &_b)
+			mul512Generic(&q, &_a, &_b)
 
-			if x.NotEqual(&y) {
-				t.Fatalf("NotEqual(%v, %v)", r[i], r[j])
+			if p != q {
+				t.Fatalf("mul512(%x, %x) = %x, expected %x", _a, _b, p, q)
 			}
-			count+=2
+			count++
 		}
-	}
 
-	test_mod := test_all
-	test_ops := test_fixed
+		sqr512(&p, &_a)
+		sqr512Generic(&q, &_a)
 
-	// a == a+m (mod m)
-	// Equal(a,b) != NotEqual(a,b) for all a,b
+		if p != q {
+			t.Fatalf("sqr512(%x) = %x, expected %x", _a, p, q)
+		}
+		count++
+	}
 
-	for i, m := range test_mod {
+	for _, m := range test_mod {
 
 		if m[3] == 0 {
 			continue
 		}
 
-		m1, err := NewModulusFromUint64(m)
+		mod, err := NewModulusFromUint64(m)
 
 		if err != nil {
 			t.Fatalf("NewModulusFromUint64() failed")
 		}
 
-		for j, _a := range test_ops {
-			a.FromUint64(m1, _a)
+		u.FromUint64(mod, m)
+		v.FromUint64(mod, m)
 
-			for k, _b := range test_ops {
-				b.FromUint64(m1, _b)
-				c.Copy(&a)
+		for _, _a := range test_random {
+			for _, _b := range test_fixed {
+				x := [8]uint64{ _b[0], _b[1], _b[2], _b[3], _a[0], _a[1], _a[2], _a[3] }
 
-				u = c.Equal(&b)
-				v = c.NotEqual(&b)
+				u.reduce8Barrett(x)
+				v.reduce8Generic(x)
 
-				if u == v {
-					t.Errorf("a==b = %v", u)
-					t.Errorf("a!=b = %v", v)
-					t.Errorf("(%v,%v,%v)\n", i, j, k);
-					t.Fatalf("%v\n%v\n%v\n", a, b, m);
+				if u.r != v.r {
+					t.Fatalf("reduce8Barrett(%x) mod %x = %x, expected %x", x, m, u.r, v.r)
 				}
+				count++
+			}
+		}
+
+		// Products of large residues, and the largest 512-bit values
+
+		if m[0] == 0 {
+			continue
+		}
+
+		mm := [4]uint64{ m[0] - 1, m[1], m[2], m[3] }
 
+		for _, _a := range append(test_random, mm, [4]uint64{ ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0) }) {
+			var xs [3][8]uint64
+
+			mul512Generic(&xs[0], &_a, &mm)
+			sqr512Generic(&xs[1], &_a)
+			xs[2] = [8]uint64{ ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0), _a[0], _a[1], _a[2], _a[3] }
+
+			for _, x := range xs {
+				u.reduce8Barrett(x)
+				v.reduce8Generic(x)
+
+				if u.r != v.r {
+					t.Fatalf("reduce8Barrett(%x) mod %x = %x, expected %x", x, m, u.r, v.r)
+				}
 				count++
 			}
 		}
@@ -683,61 +2185,93 @@ func TestEqNeq(t *testing.T) {
 	t.Logf("%v tests\n", count)
 }
 
-func TestReflexivity(t *testing.T) {
+func TestSpecialReduction(t *testing.T) {
 	var (
-		a     Residue
-		u     bool
+		u, v  Residue
 		count int
 	)
 
-	test_mod := test_all
+	moduli := []struct {
+		m   string
+		red reduction
+	}{
+		{ testPrimes[0], reductionBarrett },
+		{ testPrimes[7], reductionP224 },
+		{ testPrimes[1], reductionPseudoMersenne },
+		{ testPrimes[3], reductionPseudoMersenne },
+		{ "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", reductionPseudoMersenne },
+		{ "ffffffffffffffffffffffffffffffffffffffffffffffff0000000000000001", reductionPseudoMersenne },
+		{ "8000000000000000000000000000000000000000000000000000000000000001", reductionBarrett },
+		{ "1000000000000000000000000000000000000000000000000000000000000000", reductionPseudoMersenne },
+		{ testPrimes[2], reductionBarrett },
+		{ testPrimes[5], reductionBarrett },
+	}
+
 	test_ops := test_all
 
-	// a == a for all a
+	for _, mm := range moduli {
+		mod, err := NewModulusFromString(mm.m, 16)
 
-	for _, m := range test_mod {
+		if err != nil {
+			t.Fatalf("NewModulusFromString() failed")
+		}
 
-		if m[3] == 0 {
-			continue
+		if mod.red != mm.red {
+			t.Fatalf("Modulus %v has reduction %v, expected %v", mm.m, mod.red, mm.red)
 		}
 
-		mod, err := NewModulusFromUint64(m)
+		u.FromUint64(mod, [4]uint64{ 0, 0, 0, 0 })
+		v.FromUint64(mod, [4]uint64{ 0, 0, 0, 0 })
 
-		if err != nil {
-			t.Fatalf("NewModulusFromUint64() failed")
-		}
+		// Special reductions may give other representatives than Barrett
 
 		for _, _a := range test_ops {
-			a.FromUint64(mod, _a)
+			for _, _b := range test_ops {
+				x := [8]uint64{ _b[0], _b[1], _b[2], _b[3], _a[0], _a[1], _a[2], _a[3] }
 
-			u = a.Equal(&a)
+				u.reduce8(x).reduce4()
+				v.reduce8Generic(x).reduce4()
 
-			if u == false {
-				t.Errorf("a=%v\n", a);
-				t.Errorf("m=%v\n", m);
-				t.Fatalf("a!=a (mod %v)\n", m);
+				if u.r != v.r {
+					t.Fatalf("reduce8(%x) mod %v = %x, expected %x", x, mm.m, u.r, v.r)
+				}
+				count++
 			}
-
-			count++
 		}
 	}
 
 	t.Logf("%v tests\n", count)
 }
 
-func TestSymmetry(t *testing.T) {
+func TestMontgomery(t *testing.T) {
 	var (
-		a, b  Residue
-		u, v  bool
-		count int
+		a, b, u, v Residue
+		p, q, r    MontResidue
+		count      int
 	)
 
-	test_mod := test_all
-	test_ops := test_random
+	test_mod := test_fixed
+	test_ops := test_random[:16]
 
-	for i, m := range test_mod {
+	type op struct {
+		name string
+		mont func(x, y *MontResidue)
+		ref  func(x, y *Residue)
+	}
 
-		if m[3] == 0 {
+	ops := []op{
+		{ "Add",    func(x, y *MontResidue) { x.Add(y) }, func(x, y *Residue) { x.Add(y) } },
+		{ "Sub",    func(x, y *MontResidue) { x.Sub(y) }, func(x, y *Residue) { x.Sub(y) } },
+		{ "Neg",    func(x, y *MontResidue) { x.Neg() },  func(x, y *Residue) { x.Neg() } },
+		{ "Mul",    func(x, y *MontResidue) { x.Mul(y) }, func(x, y *Residue) { x.Mul(y) } },
+		{ "Square", func(x, y *MontResidue) { x.Square() }, func(x, y *Residue) { x.Square() } },
+		{ "Exp",    func(x, y *MontResidue) { x.Exp(y.ToUint64()) }, func(x, y *Residue) { x.Exp(y.ToUint64()) } },
+		{ "Inv",    func(x, y *MontResidue) { x.Inv() }, func(x, y *Residue) { x.Inv() } },
+	}
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 || m[0] & 1 == 0 {
 			continue
 		}
 
@@ -747,60 +2281,108 @@ func TestSymmetry(t *testing.T) {
 			t.Fatalf("NewModulusFromUint64() failed")
 		}
 
-		for j, _a := range test_ops {
-			a.FromUint64(mod, _a)
+		mm, err := NewMontModulus(mod)
 
-			for k, _b := range test_ops {
-				b.FromUint64(mod, _b)
+		if err != nil || mm.Modulus() != mod {
+			t.Fatalf("NewMontModulus() failed")
+		}
 
-				// (a==b) == (b==a)
+		for _, _a := range append(test_ops, test_fixed[:32]...) {
+			a.FromUint64(mod, _a)
+			p.FromResidue(mm, &a)
 
-				u = a.Equal(&b)
-				v = b.Equal(&a)
+			// Conversions in and out of Montgomery form
 
-				if u != v {
-					t.Errorf("a==b = %v", u)
-					t.Errorf("b==a = %v", v)
-					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
-				}
+			if p.ToResidue(&u).NotEqual(&a) || p.ToUint64() != a.ToUint64() {
+				t.Fatalf("ToResidue(FromResidue(%v)) = %v", &a, &u)
+			}
 
-				count++
+			for _, _b := range test_ops {
+				b.FromUint64(mod, _b)
+				q.FromUint64(mm, _b)
 
-				// (a!=b) == (b!=a)
+				for _, o := range ops {
+					r.Copy(&p)
+					u.Copy(&a)
 
-				u = a.NotEqual(&b)
-				v = b.NotEqual(&a)
+					o.mont(&r, &q)
+					o.ref(&u, &b)
 
-				if u != v {
-					t.Errorf("a!=b = %v", u)
-					t.Errorf("b!=a = %v", v)
-					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
+					if r.ToResidue(&v).NotEqual(&u) {
+						t.Fatalf("%v(%v, %v) = %v, expected %v", o.name, &a, &b, &v, &u)
+					}
+					count++
 				}
 
+				if p.Equal(&q) != a.Equal(&b) || p.NotEqual(&q) != a.NotEqual(&b) {
+					t.Fatalf("Equal(%v, %v) = %v", &a, &b, p.Equal(&q))
+				}
 				count++
 			}
 		}
 	}
 
+	// Even moduli are not supported
+
+	mod, _ := NewModulusFromUint64([4]uint64{ 2, 0, 0, 1 })
+
+	if _, err := NewMontModulus(mod); !errors.Is(err, ErrEvenModulus) {
+		t.Fatalf("NewMontModulus() with even modulus did not fail")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Add(&q).Sub(&q).Neg().Mul(&q).Square().Exp(test_ops[0])
+		p.Inv()
+		p.ToResidue(&u)
+		p.FromResidue(q.m, &u)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("Montgomery methods allocate")
+	}
+
 	t.Logf("%v tests\n", count)
-}
 
-func TestTransitivity(t *testing.T) {
-	t.Log("Not implemented")
+	// Residues with different moduli are incompatible
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrIncompatibleModuli) {
+			t.Fatalf("Mul() with different moduli did not fail")
+		}
+	}()
+
+	mod, _ = NewModulusFromUint64(nistp256)
+	mm, _ := NewMontModulus(mod)
+
+	r.FromUint64(mm, [4]uint64{ 3, 0, 0, 0 }).Mul(&p)
 }
 
-func TestAdditiveIdentity(t *testing.T) {
+func TestConstantTime(t *testing.T) {
 	var (
-		zero, zero_m, zero_p, a, u, v, w, x, y, z Residue
-		count                                     int
+		a, b, u, v Residue
+		count      int
 	)
 
-	test_mod := test_all
-	test_ops := test_all
+	test_mod := test_fixed
+	test_ops := test_random[:16]
 
-	// a+0 == 0+a == a
+	type op struct {
+		name string
+		ct   func(x, y *Residue)
+		ref  func(x, y *Residue)
+	}
+
+	ops := []op{
+		{ "AddCT",    func(x, y *Residue) { x.AddCT(y) },   func(x, y *Residue) { x.Add(y) } },
+		{ "SubCT",    func(x, y *Residue) { x.SubCT(y) },   func(x, y *Residue) { x.Sub(y) } },
+		{ "NegCT",    func(x, y *Residue) { x.NegCT() },    func(x, y *Residue) { x.Neg() } },
+		{ "DoubleCT", func(x, y *Residue) { x.DoubleCT() }, func(x, y *Residue) { x.Double() } },
+		{ "MulCT",    func(x, y *Residue) { x.MulCT(y) },   func(x, y *Residue) { x.Mul(y) } },
+		{ "SquareCT", func(x, y *Residue) { x.SquareCT() }, func(x, y *Residue) { x.Square() } },
+	}
 
 	for _, m := range test_mod {
+
 		if m[3] == 0 {
 			continue
 		}
@@ -811,51 +2393,68 @@ func TestAdditiveIdentity(t *testing.T) {
 			t.Fatalf("NewModulusFromUint64() failed")
 		}
 
-		zero_p.FromUint64(mod, m)	// residue value m == 0 (mod m)
-		zero.Copy(&zero_p).Sub(&zero_p)	// 0
-		zero_m.Copy(&zero).Sub(&zero_p)	// 0-m
+		for _, _a := range append(test_ops, test_fixed...) {
+			for _, _b := range test_ops {
+				a.FromUint64(mod, _a)
+				b.FromUint64(mod, _b)
 
-		for _, _a := range test_ops {
-			a.FromUint64(mod, _a)
+				for _, o := range ops {
+					u.Copy(&a)
+					v.Copy(&a)
 
-			u.Copy(&a).Add(&zero_m)
-			v.Copy(&a).Add(&zero  )
-			w.Copy(&a).Add(&zero_p)
+					o.ct(&u, &b)
+					o.ref(&v, &b)
 
-			x.Copy(&zero_m).Add(&a)
-			y.Copy(&zero  ).Add(&a)
-			z.Copy(&zero_p).Add(&a)
+					if u.ToUint64() != v.ToUint64() {
+						t.Fatalf("%v(%v, %v) = %v, expected %v", o.name, &a, &b, &u, &v)
+					}
+					count++
+				}
 
-			if a.NotEqual(&u) || a.NotEqual(&v) || a.NotEqual(&w) || a.NotEqual(&x) || a.NotEqual(&y) || a.NotEqual(&z) {
-				t.Errorf("m=%v\n", m);
-				t.Errorf("a=%v\n", a);
-				t.Errorf("u=%v\n", u);
-				t.Errorf("v=%v\n", v);
-				t.Errorf("w=%v\n", w);
-				t.Errorf("x=%v\n", x);
-				t.Errorf("y=%v\n", y);
-				t.Fatalf("z=%v\n", z);
-			}
+				// ToUint64CT and EqualCT match their variable-time counterparts
 
-			count++
+				u.Copy(&a)
+				v.Copy(&a)
+
+				if u.ToUint64CT() != v.ToUint64() {
+					t.Fatalf("ToUint64CT(%v) = %x, expected %x", &a, u.ToUint64CT(), v.ToUint64())
+				}
+
+				if a.EqualCT(&b) != a.Equal(&b) || !a.EqualCT(&u) {
+					t.Fatalf("EqualCT(%v, %v) = %v", &a, &b, a.EqualCT(&b))
+				}
+				count++
+			}
 		}
 	}
 
+	allocs := testing.AllocsPerRun(100, func() {
+		u.AddCT(&b).SubCT(&a).NegCT().DoubleCT().MulCT(&a).SquareCT()
+		u.EqualCT(&v)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("Constant-time methods allocate")
+	}
+
 	t.Logf("%v tests\n", count)
 }
 
-func TestMultiplicativeIdentity(t *testing.T) {
+func TestSelect(t *testing.T) {
 	var (
-		zero, one, one_m, one_p, a, u, v, w, x, y, z Residue
-		count                                        int
+		a, b, u, v Residue
+		eb         ExpBase
+		table      [16]Residue
+		count      int
 	)
 
-	test_mod := test_all
-	test_ops := test_all
+	test_mod := test_fixed
+	test_ops := test_random[:16]
 
-	// a*1 == 1*a == a
+	conds := []uint64{ 0, 1, 2, 1 << 63, ^uint64(0) }
 
 	for _, m := range test_mod {
+
 		if m[3] == 0 {
 			continue
 		}
@@ -866,175 +2465,242 @@ func TestMultiplicativeIdentity(t *testing.T) {
 			t.Fatalf("NewModulusFromUint64() failed")
 		}
 
-		zero.FromUint64(mod, m)	// residue value m == 0 (mod m)
-		one.FromUint64(mod, [4]uint64{ 1, 0, 0, 0 })
+		for i, _a := range test_ops {
+			a.FromUint64(mod, _a)
+			b.FromUint64(mod, test_ops[(i+1) % len(test_ops)])
 
-		one_m.Copy(&one).Sub(&zero)	// 1-m
-		one_p.Copy(&one).Add(&zero)	// 1+m
+			table[i % 16].Copy(&a)
 
-		for _, _a := range test_ops {
-			a.FromUint64(mod, _a)
+			for _, c := range conds {
+				exp, alt := &a, &b
+				if c == 0 {
+					exp, alt = &b, &a
+				}
 
-			u.Copy(&a).Mul(&one_m)
-			v.Copy(&a).Mul(&one  )
-			w.Copy(&a).Mul(&one_p)
+				if u.Select(c, &a, &b).r != exp.r {
+					t.Fatalf("Select(%v, %v, %v) = %v", c, &a, &b, &u)
+				}
 
-			x.Copy(&one_m).Mul(&a)
-			y.Copy(&one  ).Mul(&a)
-			z.Copy(&one_p).Mul(&a)
+				u.Copy(&a)
+				v.Copy(&b)
+				CondSwap(&u, &v, c)
 
-			if a.NotEqual(&u) || a.NotEqual(&v) || a.NotEqual(&w) || a.NotEqual(&x) || a.NotEqual(&y) || a.NotEqual(&z) {
-				t.Errorf("m=%v\n", m);
-				t.Errorf("a=%v\n", a);
-				t.Errorf("u=%v\n", u);
-				t.Errorf("v=%v\n", v);
-				t.Errorf("w=%v\n", w);
-				t.Errorf("x=%v\n", x);
-				t.Errorf("y=%v\n", y);
-				t.Fatalf("z=%v\n", z);
+				if u.r != alt.r || v.r != exp.r {
+					t.Fatalf("CondSwap(%v, %v, %v) = %v, %v", &a, &b, c, &u, &v)
+				}
+
+				u.Copy(&a).CondNeg(c)
+				v.Copy(&a)
+				if c != 0 {
+					v.Neg()
+				}
+
+				if u.NotEqual(&v) {
+					t.Fatalf("CondNeg(%v, %v) = %v, expected %v", &a, c, &u, &v)
+				}
+				count += 3
 			}
+		}
 
+		for i := range table {
+			if u.LookupTable(table[:], i).r != table[i].r || u.m != mod {
+				t.Fatalf("LookupTable(%v) = %v, expected %v", i, &u, &table[i])
+			}
 			count++
 		}
+
+		// ExpPrecompCT and ExpPrecomp give the same results
+
+		for _, _a := range test_ops[:4] {
+			a.FromUint64(mod, _a)
+			eb.FromResidue(&a)
+
+			for _, e := range test_ops {
+				u.ExpPrecompCT(&eb, e)
+				v.ExpPrecomp(&eb, e)
+
+				if u.NotEqual(&v) {
+					t.Fatalf("ExpPrecompCT(%v, %x) = %v, expected %v", &a, e, &u, &v)
+				}
+				count++
+			}
+		}
 	}
 
-	t.Logf("%v tests\n", count)
-}
+	// Equal moduli in different objects are compatible, and different moduli are not
 
-func TestAdditiveInverse(t *testing.T) {
-	var (
-		a, b, u, v, w Residue
-		count         int
-	)
+	m1, _ := NewModulusFromUint64(nistp256)
+	m2, _ := NewModulusFromUint64(nistp256)
+	m3, _ := NewModulusFromString(testPrimes[2], 16)
 
-	test_mod := test_all
-	test_ops := test_all
+	var r1, r2, r3, w Residue
 
-	// a+(-a) == (-a)+a == 0
+	r1.FromUint64(m1, test_ops[0])
+	r2.FromUint64(m2, test_ops[1])
+	r3.FromUint64(m3, test_ops[1])
 
-	for _, m := range test_mod {
+	for _, f := range []func(x, y *Residue){
+		func(x, y *Residue) { w.Select(1, x, y) },
+		func(x, y *Residue) { CondSwap(x, y, 1); CondSwap(x, y, 1) },
+		func(x, y *Residue) { w.LookupTable([]Residue{ *x, *y }, 1) },
+	} {
+		requireSuccess(t, f, &r1, &r2)
+		requireFailure(t, f, &r1, &r3)
+		count += 2
+	}
 
-		if m[3] == 0 {
-			continue
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("LookupTable() with index out of range did not fail")
 		}
+	}()
 
-		mod, err := NewModulusFromUint64(m)
+	allocs := testing.AllocsPerRun(100, func() {
+		u.Select(1, &a, &b)
+		CondSwap(&u, &v, 1)
+		u.CondNeg(1)
+		u.LookupTable(table[:], 5)
+		u.ExpPrecompCT(&eb, test_ops[0])
+	})
 
-		if err != nil {
-			t.Fatalf("NewModulusFromUint64() failed")
-		}
+	if allocs != 0 {
+		t.Fatalf("Constant-time selection methods allocate")
+	}
 
-		for _, _a := range test_ops {
-			a.FromUint64(mod, _a)
+	t.Logf("%v tests\n", count)
 
-			v.Copy(&a).Neg()
+	u.LookupTable(table[:], len(table))
+}
 
-			// a+(-a)
-			u.Copy(&a).Add(&v)
+func TestMultiExp(t *testing.T) {
+	var (
+		u, v, w Residue
+		count   int
+	)
 
-			// (-a)+a
-			v.Add(&a)
+	mod, err := NewModulusFromString(testPrimes[2], 16)
 
-			if u.NotEqual(&v) {
-				t.Errorf("a=%v\n", a);
-				t.Errorf("m=%v\n", m);
-				t.Errorf("a+(-a)=%v\n", u);
-				t.Fatalf("(-a)+a=%v\n", v);
-			}
+	if err != nil {
+		t.Fatalf("NewModulusFromString() failed")
+	}
 
-			count++
-		}
+	bases := make([]Residue, 1100)
+	exps := make([][4]uint64, len(bases))
+
+	for i := range bases {
+		bases[i].FromUint64(mod, test_all[(7*i + 3) % len(test_all)])
+		exps[i] = test_all[(11*i + 5) % len(test_all)]
 	}
 
-	// a == -(-a))
+	// Expected products, one Exp at a time
 
-	for _, m := range test_mod {
+	expected := func(n int, fixed []Residue, fixedExps [][4]uint64) *Residue {
+		w.SetOne(mod)
 
-		if m[3] == 0 {
-			continue
+		for i := 0; i < n; i++ {
+			w.Mul(v.Copy(&bases[i]).Exp(exps[i]))
 		}
 
-		mod, err := NewModulusFromUint64(m)
-
-		if err != nil {
-			t.Fatalf("NewModulusFromUint64() failed")
+		for i := range fixed {
+			w.Mul(v.Copy(&fixed[i]).Exp(fixedExps[i]))
 		}
 
-		for _, _a := range test_ops {
-			a.FromUint64(mod, _a)
+		return &w
+	}
 
-			u.Copy(&a).Neg().Neg()
+	for _, n := range []int{ 0, 1, 2, 3, 5, 17, 64, 1100 } {
+		u.SetZero(mod)
 
-			if u.NotEqual(&a) {
-				t.Errorf("a=%v\n", a);
-				t.Errorf("m=%v\n", m);
-				t.Fatalf("-(-a)=%v\n", u);
-			}
+		if MultiExp(&u, bases[:n], exps[:n]).NotEqual(expected(n, nil, nil)) {
+			t.Fatalf("MultiExp() of %v bases = %v, expected %v", n, &u, &w)
+		}
+		count++
+	}
 
+	// Both methods with small batches, for all window sizes
+
+	for c := 2; c <= 13; c++ {
+		for _, n := range []int{ 1, 9 } {
+			if u.pippenger(mod, bases[:n], exps[:n], c).NotEqual(expected(n, nil, nil)) {
+				t.Fatalf("pippenger() of %v bases with c = %v: %v, expected %v", n, c, &u, &w)
+			}
 			count++
 		}
 	}
 
-	test_mod = test_all
-	test_ops = test_random
-
-	// a-b == (-b)+a == -(b-a)
+	// Fixed bases with both geometries, together with variable bases
 
-	for i, m := range test_mod {
+	fixed := bases[20:23]
+	fixedExps := [][4]uint64{ nistp256, test_random[0], { 0, 0, 0, 0 } }
 
-		if m[3] == 0 {
-			continue
-		}
+	var eb ExpBase
+	eb.FromResidue(&fixed[0])
+	eb1, _ := NewExpBase(&fixed[1], 5, 3)
+	eb2, _ := NewExpBase(&fixed[2], 8, 2)
 
-		mod, err := NewModulusFromUint64(m)
+	ebs := []*ExpBase{ &eb, eb1, eb2 }
 
-		if err != nil {
-			t.Fatalf("NewModulusFromUint64() failed")
+	for _, n := range []int{ 0, 4, 1100 } {
+		if MultiExpPrecomp(&u, ebs, fixedExps, bases[:n], exps[:n]).NotEqual(expected(n, fixed, fixedExps)) {
+			t.Fatalf("MultiExpPrecomp() of %v bases = %v, expected %v", n, &u, &w)
 		}
+		count++
+	}
 
-		for j, _a := range test_ops {
-			a.FromUint64(mod, _a)
+	allocs := testing.AllocsPerRun(10, func() {
+		MultiExpPrecomp(&u, ebs, fixedExps, nil, nil)
+	})
 
-			for k, _b := range test_ops {
-				b.FromUint64(mod, _b)
+	if allocs != 0 {
+		t.Fatalf("MultiExpPrecomp() with only fixed bases allocates")
+	}
 
-				// a-b
-				u.Copy(&a).Sub(&b)
+	// The destination may be one of the bases
 
-				// (-b)+a
-				v.Copy(&b).Neg().Add(&a)
+	expected(3, nil, nil)
 
-				// -(b-a)
-				w.Copy(&b).Sub(&a).Neg()
+	if MultiExp(&bases[1], bases[:3], exps[:3]).NotEqual(&w) {
+		t.Fatalf("MultiExp() into a base = %v, expected %v", &bases[1], &w)
+	}
 
-				if u.NotEqual(&v) || u.NotEqual(&w) || v.NotEqual(&w) {
-					t.Errorf("a-b = %v", u)
-					t.Errorf("-b+a = %v", v)
-					t.Errorf("-(b-a) = %v", w)
-					t.Fatalf("(%v,%v,%v) count = %v\n", i, j, k, count);
-				}
+	// Errors
 
-				count++
+	testMultiExp := func(dst *Residue, bases []Residue, exps [][4]uint64, e error) {
+		defer func() {
+			r := recover()
+
+			if err, ok := r.(error); r == nil || (e != nil && (!ok || !errors.Is(err, e))) {
+				t.Fatalf("MultiExp() did not fail with %v", e)
 			}
-		}
+		}()
+
+		MultiExp(dst, bases, exps)
+	}
+
+	mod1, _ := NewModulusFromUint64(nistp256)
+
+	testMultiExp(&Residue{}, nil, nil, ErrUninitialized)
+	testMultiExp(&u, bases[:2], exps[:1], nil)
+	testMultiExp(&u, []Residue{ bases[0], *v.SetOne(mod1) }, exps[:2], ErrIncompatibleModuli)
+
+	if pippengerWindow(10) != 0 || pippengerWindow(10000) == 0 {
+		t.Fatalf("pippengerWindow() does not switch between methods")
 	}
 
 	t.Logf("%v tests\n", count)
 }
 
-func TestMultiplicativeInverse(t *testing.T) {
-
+func TestBatchInv(t *testing.T) {
 	var (
-		a, u, v, one              Residue
+		u                         Residue
+		src, dst                  [40]Residue
+		ok                        [40]bool
 		invertible, noninvertible int
 	)
 
 	test_mod := test_fixed
 	test_ops := test_fixed
 
-	// if 1/a exists, then
-	//	a*(1/a) == (1/a)*a == 1
-
 	for i, m := range test_mod {
 
 		if m[3] == 0 {
@@ -1047,30 +2713,57 @@ func TestMultiplicativeInverse(t *testing.T) {
 			t.Fatalf("NewModulusFromUint64() failed")
 		}
 
-		one.FromUint64(mod, [4]uint64{ 1, 0, 0, 0 })
+		// Batches of varying length, taken from consecutive test values
 
-		for j, _a := range test_ops {
-			a.FromUint64(mod, _a)
+		n := 1 + i % len(src)
 
-			// 1/a
-			u.Copy(&a)
-			if !u.Inv() {
-				noninvertible++
-				continue
+		for j := 0; j+n <= len(test_ops); j += n {
+			all := true
+
+			for k := 0; k < n; k++ {
+				src[k].FromUint64(mod, test_ops[j+k])
 			}
 
-			// a * 1/a
-			v.Copy(&a)
-			v.Mul(&u)
+			r := BatchInv(dst[:n], src[:n], ok[:n])
 
-			if v.NotEqual(&one) {
-				t.Errorf("%v,%v\n", i, j);
-				t.Fatalf("%v^2%%%v\n%v\n%v\n", a, m, u, v);
+			for k := 0; k < n; k++ {
+				inv := u.Copy(&src[k]).Inv()
+
+				if inv != ok[k] || u.NotEqual(&dst[k]) {
+					t.Fatalf("BatchInv(): %v: %v != %v", &src[k], &dst[k], &u)
+				}
+
+				if inv {
+					invertible++
+				} else {
+					noninvertible++
+				}
+
+				all = all && inv
 			}
 
-			invertible++
+			if r != all || BatchInv(dst[:n], src[:n], nil) != all {
+				t.Fatalf("BatchInv() = %v, expected %v", r, all)
+			}
 		}
 	}
+
+	for k := range src {
+		src[k].FromUint64(src[0].m, test_random[k])
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		BatchInv(dst[:], src[:], ok[:])
+	})
+
+	if allocs != 0 {
+		t.Fatalf("BatchInv() allocates")
+	}
+
+	if !BatchInv(nil, nil, nil) {
+		t.Fatalf("BatchInv() of empty batch failed")
+	}
+
 	t.Logf("%v invertible, %v noninvertible\n", invertible, noninvertible)
 }
 
@@ -1376,25 +3069,250 @@ func TestDistributiveRight(t *testing.T) {
 					u.Copy(&a)
 					u.Mul(&c)
 
-					v.Copy(&b)
-					v.Mul(&c)
+					v.Copy(&b)
+					v.Mul(&c)
+
+					u.Add(&v)
+
+					// (a+b)c
+					v.Copy(&a)
+					v.Add(&b)
+					v.Mul(&c)
+
+					if u.NotEqual(&v) {
+						t.Fatalf("%v*%v*%v%%%v\n%v\n%v\n", a, b, c, m, u, v);
+					}
+
+					count++
+				}
+			}
+		}
+	}
+	t.Logf("%v tests\n", count)
+}
+
+func TestThreeOperand(t *testing.T) {
+	var (
+		a, b, c, e, u, v, z Residue
+		count               int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_random[:16]
+
+	type op struct {
+		name string
+		f    func(z, x, y *Residue) bool
+		ref  func(x, y *Residue) bool // computes x op y in place
+	}
+
+	ops := []op{
+		{ "Sum",        func(z, x, y *Residue) bool { z.Sum(x, y); return true },        func(x, y *Residue) bool { x.Add(y); return true } },
+		{ "Difference", func(z, x, y *Residue) bool { z.Difference(x, y); return true }, func(x, y *Residue) bool { x.Sub(y); return true } },
+		{ "Product",    func(z, x, y *Residue) bool { z.Product(x, y); return true },    func(x, y *Residue) bool { x.Mul(y); return true } },
+		{ "SquareOf",   func(z, x, y *Residue) bool { z.SquareOf(x); return true },      func(x, y *Residue) bool { x.Square(); return true } },
+		{ "Quotient",   func(z, x, y *Residue) bool { return z.Quotient(x, y) },
+			func(x, y *Residue) bool {
+				var t Residue
+				ok := t.Copy(y).Inv()
+				x.Mul(&t)
+				return ok
+			},
+		},
+	}
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			for _, _b := range test_ops {
+				a.FromUint64(mod, _a)
+				b.FromUint64(mod, _b)
+
+				for _, o := range ops {
+					// Expected results for x op y and x op x
+
+					e.Copy(&a)
+					ok := o.ref(&e, &b)
+
+					u.Copy(&a)
+					v.Copy(&a)
+					okSame := o.ref(&u, &v)
+
+					check := func(r *Residue, ok, expOk bool, exp *Residue, alias string) {
+						if ok != expOk || (ok && r.NotEqual(exp)) {
+							t.Fatalf("%v (%v): %v != %v", o.name, alias, r, exp)
+						}
+						count++
+					}
+
+					z.FromUint64(mod, [4]uint64{ 0, 0, 0, 0 })
+					x, y := a, b
+					r := o.f(&z, &x, &y)
+					check(&z, r, ok, &e, "z, x, y")
+
+					x, y = a, b
+					r = o.f(&x, &x, &y)
+					check(&x, r, ok, &e, "x, x, y")
+
+					x, y = a, b
+					r = o.f(&y, &x, &y)
+					check(&y, r, ok, &e, "y, x, y")
+
+					x = a
+					r = o.f(&z, &x, &x)
+					check(&z, r, okSame, &u, "z, x, x")
+
+					x = a
+					r = o.f(&x, &x, &x)
+					check(&x, r, okSame, &u, "x, x, x")
+				}
+			}
+		}
+	}
+
+	// Equal moduli in different objects are compatible, and different moduli are not
+
+	m1, _ := NewModulusFromUint64(nistp256)
+	m2, _ := NewModulusFromUint64(nistp256)
+	m3, _ := NewModulusFromString(testPrimes[2], 16)
+
+	a.FromUint64(m1, test_ops[0])
+	b.FromUint64(m2, test_ops[1])
+	c.FromUint64(m3, test_ops[1])
+
+	for _, o := range ops {
+		if o.name == "SquareOf" {
+			continue
+		}
+
+		f := func(x, y *Residue) { o.f(&z, x, y) }
+
+		requireSuccess(t, f, &a, &b)
+		requireFailure(t, f, &a, &c)
+		count += 2
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		z.Sum(&a, &b)
+		z.Difference(&a, &b)
+		z.Product(&a, &b)
+		z.SquareOf(&a)
+		z.Quotient(&a, &b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("Three-operand methods allocate")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestMulAdd(t *testing.T) {
+	var (
+		a, b, c, e, u, v Residue
+		as, bs           [40]Residue
+		count            int
+	)
+
+	test_mod := test_fixed
+	test_ops := test_random[:8]
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			for _, _b := range test_ops {
+				a.FromUint64(mod, _a)
+				b.FromUint64(mod, _b)
+				c.FromUint64(mod, [4]uint64{ ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0) })
+
+				// c + a*b, with aliases
+
+				if u.Copy(&c).MulAdd(&a, &b); u.NotEqual(e.Product(&a, &b).Add(&c)) {
+					t.Fatalf("MulAdd(%v, %v, %v) = %v, expected %v", &c, &a, &b, &u, &e)
+				}
+
+				if u.Copy(&a).MulAdd(&u, &b); u.NotEqual(e.Product(&a, &b).Add(&a)) {
+					t.Fatalf("MulAdd(%v, %v, %v) = %v, expected %v", &a, &a, &b, &u, &e)
+				}
+
+				if u.Copy(&a).MulAdd(&u, &u); u.NotEqual(e.SquareOf(&a).Add(&a)) {
+					t.Fatalf("MulAdd(%v, %v, %v) = %v, expected %v", &a, &a, &a, &u, &e)
+				}
+
+				count += 3
+			}
+		}
+
+		// Dot products of all lengths up to 40, with all-ones values to fill the accumulator
+
+		for i := range as {
+			as[i].FromUint64(mod, test_all[(3*i + 1) % 514])
+			bs[i].FromUint64(mod, test_all[(5*i + 2) % 514])
+		}
+
+		for n := 0; n <= len(as); n++ {
+			e.SetZero(mod)
+
+			for i := 0; i < n; i++ {
+				e.Add(v.Product(&as[i], &bs[i]))
+			}
+
+			if DotProduct(&u, as[:n], bs[:n]).NotEqual(&e) {
+				t.Fatalf("DotProduct() of length %v mod %x = %v, expected %v", n, m, &u, &e)
+			}
+			count++
+		}
+	}
+
+	// Enough products of 2^256-1 to carry into the top accumulator word
+
+	mod, _ := NewModulusFromUint64(nistp256)
+
+	ones := make([]Residue, 1000)
+
+	for i := range ones {
+		ones[i].FromUint64(mod, [4]uint64{ ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0) })
+	}
+
+	e.SetZero(mod)
 
-					u.Add(&v)
+	for i := range ones {
+		e.MulAdd(&ones[i], &ones[i])
+	}
 
-					// (a+b)c
-					v.Copy(&a)
-					v.Add(&b)
-					v.Mul(&c)
+	if DotProduct(&ones[0], ones, ones).NotEqual(&e) {
+		t.Fatalf("DotProduct() of 1000 ones = %v, expected %v", &ones[0], &e)
+	}
 
-					if u.NotEqual(&v) {
-						t.Fatalf("%v*%v*%v%%%v\n%v\n%v\n", a, b, c, m, u, v);
-					}
+	allocs := testing.AllocsPerRun(100, func() {
+		u.MulAdd(&a, &b)
+		DotProduct(&u, as[:], bs[:])
+	})
 
-					count++
-				}
-			}
-		}
+	if allocs != 0 {
+		t.Fatalf("MulAdd() and DotProduct() allocate")
 	}
+
 	t.Logf("%v tests\n", count)
 }
 
@@ -1499,6 +3417,77 @@ func TestDouble(t *testing.T) {
 	t.Logf("%v tests\n", count)
 }
 
+func TestMulUint64(t *testing.T) {
+	var (
+		a, e, k, u Residue
+		count      int
+	)
+
+	test_mod := test_all
+	test_ops := test_fixed[240:270]
+
+	scalars := []uint64{ 0, 1, 2, 3, 4, 8, 19, 0x8000000000000000, 0xfffffffffffffffe, ^uint64(0) }
+
+	for _, r := range test_random[:4] {
+		scalars = append(scalars, r[0])
+	}
+
+	for _, m := range test_mod {
+
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, err := NewModulusFromUint64(m)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromUint64() failed")
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			for _, s := range scalars {
+				k.FromUint64(mod, [4]uint64{ s, 0, 0, 0 })
+
+				if u.Copy(&a).MulUint64(s); u.NotEqual(e.Product(&a, &k)) {
+					t.Fatalf("MulUint64(%v, %x) = %v, expected %v", &a, s, &u, &e)
+				}
+				count++
+			}
+
+			// Halving and doubling are inverses for odd moduli
+
+			if m[0] & 1 == 0 {
+				continue
+			}
+
+			if u.Copy(&a).Halve().Double(); u.NotEqual(&a) {
+				t.Fatalf("Halve(%v).Double() = %v", &a, &u)
+			}
+
+			if u.Copy(&a).Double().Halve(); u.NotEqual(&a) {
+				t.Fatalf("Double(%v).Halve() = %v", &a, &u)
+			}
+			count += 2
+		}
+	}
+
+	mod, _ := NewModulusFromString("ffffffff00000001000000000000000000000000fffffffffffffffffffffffe", 16)
+
+	func() {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, ErrEvenModulus) {
+				t.Fatalf("Halve() with an even modulus did not panic")
+			}
+		}()
+
+		u.SetOne(mod).Halve()
+	}()
+
+	t.Logf("%v tests\n", count)
+}
+
 func TestSquare(t *testing.T) {
 	var (
 		a, b, u, v, w Residue
@@ -1615,58 +3604,399 @@ func TestSquare(t *testing.T) {
 
 func TestExponentiation(t *testing.T) {
 	var (
-		a, b, c Residue
-		eb      ExpBase
-		count   int
+		a, b, c Residue
+		eb      ExpBase
+		count   int
+	)
+
+	test_ops := test_fixed
+
+	// a^m == a
+
+	mod, err := NewModulusFromUint64(nistp256)
+
+	if err != nil {
+		t.Fatalf("NewModulusFromUint64() failed")
+	}
+
+	for i, _a := range test_ops {
+		a.FromUint64(mod, _a)
+
+		invertible := b.Copy(&a).Inv()
+
+		if !invertible {
+			// nistp256 is prime, so only 0 lacks an inverse,
+			// and 0 is the only fixed value = 0 (mod nistp256)
+			if _a[3] | _a[2] | _a[1] | _a[0] != 0 {
+				t.Fatalf("%v\n", &a)
+			}
+			continue
+		}
+
+		// b = a^m
+		eb.FromResidue(&a)
+		b.ExpPrecomp(&eb, nistp256)
+
+		// c = a^m
+		c.Copy(&a).Exp(nistp256)
+
+		if a.NotEqual(&b) || a.NotEqual(&c) {
+			t.Errorf("%v", i)
+			t.Errorf("%v\n", &a)
+			t.Errorf("%v\n", &b)
+			t.Fatalf("%v\n", &c)
+		}
+
+		count++
+	}
+	t.Logf("%v tests\n", count)
+}
+
+func TestExpSliding(t *testing.T) {
+	var (
+		a, u, v          Residue
+		bm, ba, be, bx   big.Int
+		count            int
+	)
+
+	test_ops := test_fixed
+
+	for _, p := range testPrimes {
+		mod, err := NewModulusFromString(p, 16)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromString() failed")
+		}
+
+		bm.SetString(p, 16)
+
+		// Small exponents, (p-1)/2, (p+1)/4, p-2, and exponents longer than 256 bits
+
+		exps := []*big.Int{ big.NewInt(0), big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(65537), big.NewInt(-1), big.NewInt(-5) }
+
+		exps = append(exps, new(big.Int).Rsh(new(big.Int).Sub(&bm, big.NewInt(1)), 1))
+		exps = append(exps, new(big.Int).Rsh(new(big.Int).Add(&bm, big.NewInt(1)), 2))
+		exps = append(exps, new(big.Int).Sub(&bm, big.NewInt(2)))
+		exps = append(exps, new(big.Int).Mul(&bm, &bm))
+		exps = append(exps, new(big.Int).Lsh(big.NewInt(0x5555), 900))
+
+		for _, e := range exps {
+			for _, _a := range test_ops[250:270] {
+				a.FromUint64(mod, _a)
+				a.ToBig(&ba)
+
+				// Expected value, with 0 for a missing inverse
+
+				be.Set(e)
+
+				if e.Sign() < 0 {
+					if bx.ModInverse(&ba, &bm) == nil {
+						bx.SetInt64(0)
+					}
+					ba.Set(&bx)
+					be.Neg(e)
+				}
+
+				bx.Exp(&ba, &be, &bm)
+
+				if u.Copy(&a).ExpBig(e).ToBig(&ba).Cmp(&bx) != 0 {
+					t.Fatalf("ExpBig(%v, %v) = %v, expected %x", &a, e, &u, &bx)
+				}
+
+				if e.Sign() >= 0 {
+					b := append(make([]byte, 3), e.Bytes()...)
+
+					if v.Copy(&a).ExpBytes(b); v.NotEqual(&u) {
+						t.Fatalf("ExpBytes(%v, %x) = %v, expected %v", &a, b, &v, &u)
+					}
+				}
+
+				if e.IsUint64() {
+					if v.Copy(&a).ExpUint64(e.Uint64()); v.NotEqual(&u) {
+						t.Fatalf("ExpUint64(%v, %v) = %v, expected %v", &a, e, &v, &u)
+					}
+				}
+
+				count++
+			}
+		}
+
+		if v.Copy(&a).ExpBytes(nil); !v.IsOne() {
+			t.Fatalf("ExpBytes(%v, nil) = %v", &a, &v)
+		}
+	}
+
+	e := new(big.Int).Lsh(big.NewInt(1), 700)
+	b := e.Bytes()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		u.ExpUint64(65537).ExpBytes(b).ExpBig(e)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("Exponentiation allocates")
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+func TestExpBase(t *testing.T) {
+	var (
+		a, u, v, w Residue
+		count      int
+	)
+
+	test_ops := test_fixed
+
+	mod, err := NewModulusFromString(testPrimes[2], 16)
+
+	if err != nil {
+		t.Fatalf("NewModulusFromString() failed")
+	}
+
+	geometries := [][2]int{ {1, 1}, {1, 7}, {2, 5}, {3, 3}, {4, 2}, {5, 2}, {6, 7}, {8, 1}, {8, 8}, {1, 256} }
+
+	for _, g := range geometries {
+		for _, _a := range test_ops[500:503] {
+			a.FromUint64(mod, _a)
+
+			eb, err := NewExpBase(&a, g[0], g[1])
+
+			if err != nil {
+				t.Fatalf("NewExpBase(%v, %v) failed", g[0], g[1])
+			}
+
+			for _, e := range test_random {
+				u.ExpPrecomp(eb, e)
+				v.ExpPrecompCT(eb, e)
+				w.Copy(&a).Exp(e)
+
+				if u.NotEqual(&w) || v.NotEqual(&w) {
+					t.Fatalf("ExpPrecomp(%v, %x) with %v = %v, %v, expected %v", &a, e, g, &u, &v, &w)
+				}
+				count++
+			}
+		}
+	}
+
+	// FromResidue after NewExpBase gives the default geometry
+
+	eb, _ := NewExpBase(&a, 3, 3)
+	eb.FromResidue(&a)
+
+	if u.ExpPrecomp(eb, nistp256); u.NotEqual(w.Copy(&a).Exp(nistp256)) {
+		t.Fatalf("ExpPrecomp() after FromResidue() = %v, expected %v", &u, &w)
+	}
+
+	// FromResidue is NewExpBase with 4 teeth and 2 tables, without allocation
+
+	var fb ExpBase
+
+	eb, _ = NewExpBase(&a, 4, 2)
+	fb.FromResidue(&a)
+
+	fe := fb.entries()
+
+	for i, e := range eb.entries() {
+		if e.NotEqual(&fe[i]) {
+			t.Fatalf("FromResidue() entry %v = %v, expected %v", i, &fe[i], &e)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		var e ExpBase
+
+		e.FromResidue(&a)
+		u.ExpPrecomp(&e, test_random[0])
+		v.ExpPrecompCT(&e, test_random[0])
+	})
+
+	if allocs != 0 || fb.t != nil || len(fe) != 32 {
+		t.Fatalf("FromResidue() and ExpPrecomp() allocate")
+	}
+
+	for _, g := range [][2]int{ {0, 1}, {9, 1}, {1, 0}, {2, 129}, {-1, -1}, {0, 0} } {
+		if _, err := NewExpBase(&a, g[0], g[1]); !errors.Is(err, ErrExpBaseGeometry) {
+			t.Fatalf("NewExpBase(%v, %v) did not fail", g[0], g[1])
+		}
+
+		if _, _, _, err := ExpBaseCost(g[0], g[1]); !errors.Is(err, ErrExpBaseGeometry) {
+			t.Fatalf("ExpBaseCost(%v, %v) did not fail", g[0], g[1])
+		}
+	}
+
+	if e, s, m, err := ExpBaseCost(4, 2); e != 32 || s != 31 || m != 63 || err != nil {
+		t.Fatalf("ExpBaseCost(4, 2) = %v, %v, %v, %v", e, s, m, err)
+	}
+
+	if e, s, m, err := ExpBaseCost(8, 8); e != 2048 || s != 3 || m != 31 || err != nil {
+		t.Fatalf("ExpBaseCost(8, 8) = %v, %v, %v, %v", e, s, m, err)
+	}
+
+	t.Logf("%v tests\n", count)
+}
+
+// Primes of the form 3 (mod 4), 5 (mod 8) and 1 (mod 8), the latter with 2-adic valuations of m-1 from 4 to 96
+var testPrimes = []string{
+	"ffffffff00000001000000000000000000000000ffffffffffffffffffffffff", // P-256
+	"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", // secp256k1
+	"30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47", // BN254
+	"7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", // 2^255-19
+	"1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed", // Ed25519 group order
+	"ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551", // P-256 group order
+	"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", // secp256k1 group order
+	"ffffffffffffffffffffffffffffffff000000000000000000000001",         // P-224
+}
+
+func TestSqrt(t *testing.T) {
+	var (
+		a, r, u       Residue
+		bm, b, bs     big.Int
+		roots, nonres int
+	)
+
+	test_ops := test_all
+
+	for _, p := range testPrimes {
+		mod, err := NewModulusFromString(p, 16)
+
+		if err != nil {
+			t.Fatalf("NewModulusFromString() failed")
+		}
+
+		bm.SetString(p, 16)
+
+		if !bm.ProbablyPrime(20) {
+			t.Fatalf("%v is not prime", p)
+		}
+
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
+
+			// sqrt(a^2) exists
+
+			r.SquareOf(&a)
+			u.Copy(&r)
+
+			if !r.Sqrt() || r.Square().NotEqual(&u) {
+				t.Fatalf("Sqrt(%v) failed", &u)
+			}
+
+			// sqrt(a) exists iff a is a quadratic residue
+
+			a.ToBig(&b)
+
+			ok := bs.ModSqrt(&b, &bm) != nil
+
+			r.Copy(&a)
+
+			if r.Sqrt() != ok {
+				t.Fatalf("Sqrt(%v) != %v", &a, ok)
+			}
+
+			if ok {
+				if r.Square().NotEqual(&a) {
+					t.Fatalf("Sqrt(%v)^2 != %v", &a, &a)
+				}
+				roots++
+			} else {
+				if !r.IsZero() {
+					t.Fatalf("Sqrt(%v) did not set 0", &a)
+				}
+				nonres++
+			}
+		}
+
+		allocs := testing.AllocsPerRun(10, func() {
+			r.Copy(&u).Sqrt()
+		})
+
+		if allocs != 0 {
+			t.Fatalf("Sqrt() allocates")
+		}
+	}
+
+	// Composite moduli: any square root returned must be correct
+
+	for _, m := range test_fixed {
+		if m[3] == 0 {
+			continue
+		}
+
+		mod, _ := NewModulusFromUint64(m)
+
+		for _, _a := range test_random[:4] {
+			a.FromUint64(mod, _a)
+			r.Copy(&a)
+
+			if r.Sqrt() && r.Square().NotEqual(&a) {
+				t.Fatalf("Sqrt(%v)^2 != %v", &a, &a)
+			}
+		}
+	}
+
+	t.Logf("%v square roots, %v non-residues\n", roots, nonres)
+}
+
+func TestLegendreJacobi(t *testing.T) {
+	var (
+		a     Residue
+		bm, b big.Int
+		count int
 	)
 
-	test_ops := test_fixed
+	test_ops := test_all
 
-	// a^m == a
+	for _, p := range testPrimes {
+		mod, _ := NewModulusFromString(p, 16)
+		bm.SetString(p, 16)
 
-	mod, err := NewModulusFromUint64(nistp256)
+		for _, _a := range test_ops {
+			a.FromUint64(mod, _a)
 
-	if err != nil {
-		t.Fatalf("NewModulusFromUint64() failed")
-	}
+			j := big.Jacobi(a.ToBig(&b), &bm)
 
-	for i, _a := range test_ops {
-		a.FromUint64(mod, _a)
+			if a.Legendre() != j || a.Jacobi() != j {
+				t.Fatalf("Legendre(%v) = %v, Jacobi(%v) = %v, expected %v", &a, a.Legendre(), &a, a.Jacobi(), j)
+			}
 
-		invertible := b.Copy(&a).Inv()
+			count++
+		}
+	}
 
-		if !invertible {
-			// nistp256 is prime, so only 0 lacks an inverse,
-			// and 0 is the only fixed value = 0 (mod nistp256)
-			if _a[3] | _a[2] | _a[1] | _a[0] != 0 {
-				t.Fatalf("0X%016X%016X%016X%016X\n",
-					a.r[3], a.r[2], a.r[1], a.r[0])
-			}
+	// Jacobi symbol for odd composite moduli
+
+	for _, m := range test_all {
+		if m[3] == 0 || m[0] & 1 == 0 {
 			continue
 		}
 
-		// b = a^m
-		eb.FromResidue(&a)
-		b.ExpPrecomp(&eb, nistp256)
+		mod, _ := NewModulusFromUint64(m)
+		toBig(&bm, m)
 
-		// c = a^m
-		c.Copy(&a).Exp(nistp256)
+		for _, _a := range test_fixed {
+			a.FromUint64(mod, _a)
 
-		if a.NotEqual(&b) || a.NotEqual(&c) {
-			t.Errorf("%v", i)
-			t.Errorf("0X%016X%016X%016X%016X\n",
-				a.r[3], a.r[2], a.r[1], a.r[0])
-			t.Errorf("0X%016X%016X%016X%016X\n",
-				nistp256[3], nistp256[2], nistp256[1], nistp256[0])
-			t.Errorf("0X%016X%016X%016X%016X\n",
-				b.r[3], b.r[2], b.r[1], b.r[0])
-			t.Fatalf("0X%016X%016X%016X%016X\n",
-				c.r[3], c.r[2], c.r[1], c.r[0])
-		}
+			j := big.Jacobi(a.ToBig(&b), &bm)
 
-		count++
+			if a.Jacobi() != j {
+				t.Fatalf("Jacobi(%v) = %v, expected %v", &a, a.Jacobi(), j)
+			}
+
+			count++
+		}
 	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrEvenModulus) {
+			t.Fatalf("Jacobi() with even modulus did not fail")
+		}
+	}()
+
 	t.Logf("%v tests\n", count)
+
+	mod, _ := NewModulusFromUint64([4]uint64{ 2, 0, 0, 1 })
+	a.FromUint64(mod, [4]uint64{ 3, 0, 0, 0 }).Jacobi()
 }
 
 var (
@@ -1683,9 +4013,26 @@ func BenchmarkMod256(b *testing.B) {
 
 	b.Run("Square", benchmarkSquare)
 	b.Run("Mul", benchmarkMul)
+	b.Run("MulAdd", benchmarkMulAdd)
+	b.Run("MulUint64", benchmarkMulUint64)
+	b.Run("Halve", benchmarkHalve)
+	b.Run("DotProduct16", benchmarkDotProduct16)
+	b.Run("MulBarrett", benchmarkMulBarrett)
+	b.Run("MontMul", benchmarkMontMul)
 	b.Run("Inv", benchmarkInv)
+	b.Run("InvCT", benchmarkInvCT)
+	b.Run("BatchInv", benchmarkBatchInv)
+	b.Run("MultiExp16", benchmarkMultiExp16)
 	b.Run("Exp", benchmarkExp)
+	b.Run("ExpUint64", benchmarkExpUint64)
+	b.Run("ExpBig", benchmarkExpBig)
 	b.Run("ExpPrecomp", benchmarkExpPrecomp)
+	b.Run("ExpPrecompCT", benchmarkExpPrecompCT)
+	b.Run("ExpPrecomp8x8", benchmarkExpPrecomp8x8)
+	b.Run("MontExp", benchmarkMontExp)
+	b.Run("Sqrt", benchmarkSqrt)
+	b.Run("Legendre", benchmarkLegendre)
+	b.Run("Jacobi", benchmarkJacobi)
 }
 
 func benchmarkNeg(b *testing.B) {
@@ -1772,6 +4119,89 @@ func benchmarkMul(b *testing.B) {
 	}
 }
 
+func benchmarkMulAdd(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.MulAdd(&x, &y)
+		y.MulAdd(&y, &x)
+	}
+}
+
+func benchmarkMulUint64(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.MulUint64(0xfedcba9876543210)
+		y.MulUint64(3)
+	}
+}
+
+func benchmarkHalve(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.Halve()
+		y.Halve()
+	}
+}
+
+func benchmarkDotProduct16(b *testing.B) {
+	var (
+		u      Residue
+		as, bs [16]Residue
+	)
+
+	m, _ := NewModulusFromUint64(nistp256)
+
+	for i := range as {
+		as[i].FromUint64(m, test_random[i])
+		bs[i].FromUint64(m, test_random[i + 16])
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i += len(as) {
+		DotProduct(&u, as[:], bs[:])
+	}
+}
+
+func benchmarkMulBarrett(b *testing.B) {
+	m, _ := NewModulusFromString(testPrimes[2], 16)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.Mul(&y)
+		y.Mul(&x)
+	}
+}
+
+func benchmarkMontMul(b *testing.B) {
+	var p, q MontResidue
+
+	m, _ := NewModulusFromUint64(nistp256)
+	mm, _ := NewMontModulus(m)
+
+	p.FromUint64(mm, [4]uint64{257, 479, 487, 491})
+	q.FromUint64(mm, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		p.Mul(&q)
+		q.Mul(&p)
+	}
+}
+
 func benchmarkInv(b *testing.B) {
 	var (
 		a     Residue
@@ -1837,6 +4267,88 @@ OuterLoop:
 	}
 }
 
+func benchmarkExpUint64(b *testing.B) {
+	var a Residue
+
+	m, _ := NewModulusFromUint64(nistp256)
+
+	a.FromUint64(m, [4]uint64{257, 479, 487, 491})
+
+	for i := 0; i < b.N; i++ {
+		a.ExpUint64(65537)
+	}
+}
+
+func benchmarkExpBig(b *testing.B) {
+	var a Residue
+
+	m, _ := NewModulusFromUint64(nistp256)
+
+	// (p+1)/4, 254 bits
+
+	e := m.ToBig()
+	e.Add(e, big.NewInt(1)).Rsh(e, 2)
+
+	a.FromUint64(m, [4]uint64{257, 479, 487, 491})
+
+	for i := 0; i < b.N; i++ {
+		a.ExpBig(e)
+	}
+}
+
+func benchmarkExpPrecomp8x8(b *testing.B) {
+	var a, u Residue
+
+	m, _ := NewModulusFromUint64(nistp256)
+
+	a.FromUint64(m, [4]uint64{257, 479, 487, 491})
+
+	eb, _ := NewExpBase(&a, 8, 8)
+
+	for i := 0; i < b.N; i++ {
+		u.ExpPrecomp(eb, test_random[i % len(test_random)])
+	}
+}
+
+func benchmarkMontExp(b *testing.B) {
+	var (
+		a     MontResidue
+		count int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a ^ a % m
+
+OuterLoop:
+	for {
+		for _, m := range test_mod {
+
+			if m[3] == 0 || m[0] & 1 == 0 {
+				continue
+			}
+
+			mod, _ := NewModulusFromUint64(m)
+			mm, _ := NewMontModulus(mod)
+
+			for _, _a := range test_ops {
+				a.FromUint64(mm, _a)
+
+				// a = a^a
+				a.Exp(_a)
+				a.Exp(_a)
+
+				count += 2
+
+				if count >= b.N {
+					break OuterLoop
+				}
+			}
+		}
+	}
+}
+
 func benchmarkExpPrecomp(b *testing.B) {
 	var (
 		a, u  Residue
@@ -1878,3 +4390,130 @@ OuterLoop:
 		}
 	}
 }
+
+func benchmarkExpPrecompCT(b *testing.B) {
+	var (
+		a, u  Residue
+		eb    ExpBase
+		count int
+	)
+
+	test_mod := test_all
+	test_ops := test_all
+
+	// a ^ e % m
+
+OuterLoop:
+	for {
+		for _, m := range test_mod {
+
+			if m[3] == 0 {
+				continue
+			}
+
+			mod, _ := NewModulusFromUint64(m)
+
+			for _, _a := range test_ops {
+				a.FromUint64(mod, _a)
+
+				eb.FromResidue(&a)
+				for _, e := range test_ops {
+
+					u.ExpPrecompCT(&eb, e)
+					u.ExpPrecompCT(&eb, e)
+
+					count += 2
+
+					if count >= b.N {
+						break OuterLoop
+					}
+				}
+			}
+		}
+	}
+}
+
+func benchmarkSqrt(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.Square().Sqrt()
+		y.Square().Sqrt()
+	}
+}
+
+func benchmarkLegendre(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.Legendre()
+		y.Legendre()
+	}
+}
+
+func benchmarkJacobi(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.Jacobi()
+		y.Jacobi()
+	}
+}
+
+func benchmarkBatchInv(b *testing.B) {
+	var src, dst [256]Residue
+
+	mod, _ := NewModulusFromUint64(nistp256)
+
+	for i := range src {
+		src[i].FromUint64(mod, test_random[i % len(test_random)])
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i += len(src) {
+		BatchInv(dst[:], src[:], nil)
+	}
+}
+
+func benchmarkMultiExp16(b *testing.B) {
+	var (
+		u     Residue
+		bases [16]Residue
+		exps  [16][4]uint64
+	)
+
+	mod, _ := NewModulusFromUint64(nistp256)
+
+	for i := range bases {
+		bases[i].FromUint64(mod, test_random[i])
+		exps[i] = test_random[i + 16]
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i += len(bases) {
+		MultiExp(&u, bases[:], exps[:])
+	}
+}
+
+func benchmarkInvCT(b *testing.B) {
+	m, _ := NewModulusFromUint64(nistp256)
+
+	x.FromUint64(m, [4]uint64{257, 479, 487, 491})
+	y.FromUint64(m, [4]uint64{997, 499, 503, 509})
+
+	for i := 0; i < b.N; i+=2 {
+		x.InvCT()
+		y.InvCT()
+	}
+}
@@ -5,7 +5,7 @@
 package mod256
 
 import (
-	"errors"
+	"sync"
 )
 
 // Modulus contains a modulus `m` as well as derived values that help speed up computations.
@@ -15,13 +15,19 @@ type Modulus struct {
 	mu   [5]uint64 // reciprocal
 	mmu0 [4]uint64 // m*(mu/2^256 + 0)
 	mmu1 [4]uint64 // m*(mu/2^256 + 1) % 2^256
+
+	red reduction // reduction method for 512-bit values
+	k   [4]uint64 // 2^256 mod m, for special reductions
+
+	sqrt     sqrtConstants // computed on first use by Sqrt
+	sqrtOnce sync.Once
 }
 
 // NewModulusFromUint64 creates a new modulus object from a little-endian array of uint64.
 func NewModulusFromUint64(m [4]uint64) (z *Modulus, err error) {
 
 	if m[3] == 0 {
-		return nil, errors.New("Modulus < 2^192")
+		return nil, ErrModulusTooSmall
 	}
 
 
@@ -37,6 +43,8 @@ func NewModulusFromUint64(m [4]uint64) (z *Modulus, err error) {
 	mmu0(z)
 	mmu1(z)
 
+	z.initReduction()
+
 	return
 }
 
@@ -0,0 +1,350 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// MontModulus contains an odd modulus and the constants for Montgomery multiplication.
+type MontModulus struct {
+	m   *Modulus  // same modulus, for conversion and inversion
+	inv uint64    // -1/m mod 2^64
+	one [4]uint64 // 2^256 mod m
+	r2  [4]uint64 // 2^512 mod m
+	r3  [4]uint64 // 2^768 mod m
+}
+
+// MontResidue contains a residue in Montgomery form, and the pointer to its modulus.
+// The residue x is stored as the canonical representative of x*2^256 mod m.
+type MontResidue struct {
+	m *MontModulus
+	r [4]uint64
+}
+
+// NewMontModulus creates a Montgomery modulus object from an odd modulus.
+func NewMontModulus(m *Modulus) (*MontModulus, error) {
+	var t Residue
+
+	if m.m[0] & 1 == 0 {
+		return nil, ErrEvenModulus
+	}
+
+	z := &MontModulus{ m: m }
+
+	// Newton iteration doubles the number of correct low bits, starting from 3
+
+	inv := m.m[0]
+	for i := 0; i < 5; i++ {
+		inv *= 2 - m.m[0] * inv
+	}
+	z.inv = -inv
+
+	t.SetOne(m).append256([4]uint64{ 0, 0, 0, 0 })
+	z.one = t.ToUint64()
+
+	t.append256([4]uint64{ 0, 0, 0, 0 })
+	z.r2 = t.ToUint64()
+
+	montMul(&z.r3, &z.r2, &z.r2, z)
+
+	return z, nil
+}
+
+// Modulus returns the modulus as a Barrett modulus object.
+func (m *MontModulus) Modulus() *Modulus {
+	return m.m
+}
+
+// FromUint64 sets the residue value from a little-endian array of uint64.
+func (z *MontResidue) FromUint64(m *MontModulus, x [4]uint64) *MontResidue {
+	z.m = m
+	montMul(&z.r, &x, &m.r2, m)
+	return z
+}
+
+// ToUint64 returns an array with the canonical representative of the residue class.
+func (z *MontResidue) ToUint64() (r [4]uint64) {
+	montMul(&r, &z.r, &[4]uint64{ 1, 0, 0, 0 }, z.m)
+	return r
+}
+
+// FromResidue converts a residue to Montgomery form.
+func (z *MontResidue) FromResidue(m *MontModulus, x *Residue) *MontResidue {
+	if x.m != m.m {
+		if x.m.m != m.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	return z.FromUint64(m, x.r)
+}
+
+// ToResidue converts a residue from Montgomery form, and stores it in x.
+func (z *MontResidue) ToResidue(x *Residue) *Residue {
+	x.m = z.m.m
+	x.r = z.ToUint64()
+	return x
+}
+
+// Copy copies one residue to another.
+// Both the residue value and the modulus pointer are copied.
+func (z *MontResidue) Copy(x *MontResidue) *MontResidue {
+	z.m = x.m
+	z.r = x.r
+	return z
+}
+
+// checkCompatible panics if the residues have different moduli.
+func (z *MontResidue) checkCompatible(x *MontResidue) {
+	if z.m != x.m {
+		if z.m.m.m != x.m.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+}
+
+// Add computes the sum of two residues.
+func (z *MontResidue) Add(x *MontResidue) *MontResidue {
+	z.checkCompatible(x)
+
+	m := &z.m.m.m
+
+	t0, c := Add64(z.r[0], x.r[0], 0)
+	t1, c := Add64(z.r[1], x.r[1], c)
+	t2, c := Add64(z.r[2], x.r[2], c)
+	t3, c := Add64(z.r[3], x.r[3], c)
+
+	u0, b := Sub64(t0, m[0], 0)
+	u1, b := Sub64(t1, m[1], b)
+	u2, b := Sub64(t2, m[2], b)
+	u3, b := Sub64(t3, m[3], b)
+
+	// Subtract m if the sum is at least m
+
+	if c != 0 || b == 0 {
+		t3, t2, t1, t0 = u3, u2, u1, u0
+	}
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = t3, t2, t1, t0
+
+	return z
+}
+
+// Sub computes the sum of a residue and the negation of a second residue.
+func (z *MontResidue) Sub(x *MontResidue) *MontResidue {
+	z.checkCompatible(x)
+
+	z.r = montSub(&z.r, &x.r, &z.m.m.m)
+
+	return z
+}
+
+// Neg computes the negation (additive inverse) of a residue.
+func (z *MontResidue) Neg() *MontResidue {
+	z.r = montSub(&[4]uint64{ 0, 0, 0, 0 }, &z.r, &z.m.m.m)
+
+	return z
+}
+
+// montSub computes x-y mod m for canonical x and y.
+func montSub(x, y, m *[4]uint64) [4]uint64 {
+	t0, b := Sub64(x[0], y[0], 0)
+	t1, b := Sub64(x[1], y[1], b)
+	t2, b := Sub64(x[2], y[2], b)
+	t3, b := Sub64(x[3], y[3], b)
+
+	// Add m if the difference is negative
+
+	if b != 0 {
+		var c uint64
+
+		t0, c = Add64(t0, m[0], 0)
+		t1, c = Add64(t1, m[1], c)
+		t2, c = Add64(t2, m[2], c)
+		t3, _ = Add64(t3, m[3], c)
+	}
+
+	return [4]uint64{ t0, t1, t2, t3 }
+}
+
+// Mul computes the product of two residues.
+func (z *MontResidue) Mul(x *MontResidue) *MontResidue {
+	z.checkCompatible(x)
+
+	montMul(&z.r, &z.r, &x.r, z.m)
+
+	return z
+}
+
+// Square computes the square of a residue.
+func (z *MontResidue) Square() *MontResidue {
+	montMul(&z.r, &z.r, &z.r, z.m)
+
+	return z
+}
+
+// Exp performs modular exponentiation, using a fixed window of 4 bits.
+// It performs 252 squarings and up to 77 multiplications.
+func (z *MontResidue) Exp(x [4]uint64) *MontResidue {
+	var t [16]MontResidue
+
+	t[0].m = z.m
+	t[0].r = z.m.one
+
+	t[1].Copy(z)
+
+	for i := 2; i < 16; i++ {
+		t[i].Copy(&t[i-1]).Mul(z)
+	}
+
+	z.Copy(&t[x[3] >> 60])
+
+	for i := 62; i >= 0; i-- {
+		z.Square().Square().Square().Square()
+
+		j := (x[i / 16] >> (uint(i % 16) * 4)) & 15
+
+		if j != 0 {
+			z.Mul(&t[j])
+		}
+	}
+
+	return z
+}
+
+// Inv computes the (multiplicative) inverse of a residue, if it exists.
+// Returns true if the inverse exists, otherwise z is set to 0 and false is returned.
+func (z *MontResidue) Inv() bool {
+	var t Residue
+
+	// (x*2^256)^-1 * 2^768 / 2^256 = x^-1 * 2^256
+
+	t.FromUint64(z.m.m, z.r)
+
+	ok := t.Inv()
+
+	t.reduce4()
+	montMul(&z.r, &t.r, &z.m.r3, z.m)
+
+	return ok
+}
+
+// Equal compares one residue to another, returns true when equal.
+func (x *MontResidue) Equal(y *MontResidue) bool {
+	if x.m != y.m && x.m.m.m != y.m.m.m {
+		return false
+	}
+
+	return x.r == y.r
+}
+
+// NotEqual compares one residue to another, returns true when different.
+func (x *MontResidue) NotEqual(y *MontResidue) bool {
+	return !x.Equal(y)
+}
+
+// montMul computes x*y/2^256 mod m for x < 2^256 and y < m, using the
+// Coarsely Integrated Operand Scanning (CIOS) method. The result is canonical.
+func montMul(z, x, y *[4]uint64, m *MontModulus) {
+	var t0, t1, t2, t3, t4, t5, c, q uint64
+
+	n := &m.m.m
+
+	// t = (t + x*y[0] + q*n) / 2^64, with q chosen so that the low word is 0
+
+	c, t0 = madd(x[0], y[0], t0, 0)
+	c, t1 = madd(x[1], y[0], t1, c)
+	c, t2 = madd(x[2], y[0], t2, c)
+	c, t3 = madd(x[3], y[0], t3, c)
+	t4, t5 = Add64(t4, c, 0)
+
+	q = t0 * m.inv
+
+	c, _  = madd(q, n[0], t0, 0)
+	c, t0 = madd(q, n[1], t1, c)
+	c, t1 = madd(q, n[2], t2, c)
+	c, t2 = madd(q, n[3], t3, c)
+	t3, c = Add64(t4, c, 0)
+	t4 = t5 + c
+
+	// t = (t + x*y[1] + q*n) / 2^64
+
+	c, t0 = madd(x[0], y[1], t0, 0)
+	c, t1 = madd(x[1], y[1], t1, c)
+	c, t2 = madd(x[2], y[1], t2, c)
+	c, t3 = madd(x[3], y[1], t3, c)
+	t4, t5 = Add64(t4, c, 0)
+
+	q = t0 * m.inv
+
+	c, _  = madd(q, n[0], t0, 0)
+	c, t0 = madd(q, n[1], t1, c)
+	c, t1 = madd(q, n[2], t2, c)
+	c, t2 = madd(q, n[3], t3, c)
+	t3, c = Add64(t4, c, 0)
+	t4 = t5 + c
+
+	// t = (t + x*y[2] + q*n) / 2^64
+
+	c, t0 = madd(x[0], y[2], t0, 0)
+	c, t1 = madd(x[1], y[2], t1, c)
+	c, t2 = madd(x[2], y[2], t2, c)
+	c, t3 = madd(x[3], y[2], t3, c)
+	t4, t5 = Add64(t4, c, 0)
+
+	q = t0 * m.inv
+
+	c, _  = madd(q, n[0], t0, 0)
+	c, t0 = madd(q, n[1], t1, c)
+	c, t1 = madd(q, n[2], t2, c)
+	c, t2 = madd(q, n[3], t3, c)
+	t3, c = Add64(t4, c, 0)
+	t4 = t5 + c
+
+	// t = (t + x*y[3] + q*n) / 2^64
+
+	c, t0 = madd(x[0], y[3], t0, 0)
+	c, t1 = madd(x[1], y[3], t1, c)
+	c, t2 = madd(x[2], y[3], t2, c)
+	c, t3 = madd(x[3], y[3], t3, c)
+	t4, t5 = Add64(t4, c, 0)
+
+	q = t0 * m.inv
+
+	c, _  = madd(q, n[0], t0, 0)
+	c, t0 = madd(q, n[1], t1, c)
+	c, t1 = madd(q, n[2], t2, c)
+	c, t2 = madd(q, n[3], t3, c)
+	t3, c = Add64(t4, c, 0)
+	t4 = t5 + c
+
+	// t < 2m, subtract m if t >= m
+
+	u0, b := Sub64(t0, n[0], 0)
+	u1, b := Sub64(t1, n[1], b)
+	u2, b := Sub64(t2, n[2], b)
+	u3, b := Sub64(t3, n[3], b)
+	_,  b  = Sub64(t4,    0, b)
+
+	if b == 0 {
+		t3, t2, t1, t0 = u3, u2, u1, u0
+	}
+
+	z[3], z[2], z[1], z[0] = t3, t2, t1, t0
+}
+
+// madd computes the 128-bit value x*y + a + b.
+func madd(x, y, a, b uint64) (hi, lo uint64) {
+	var c uint64
+
+	hi, lo = Mul64(x, y)
+	lo, c = Add64(lo, a, 0)
+	hi += c
+	lo, c = Add64(lo, b, 0)
+	hi += c
+
+	return hi, lo
+}
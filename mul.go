@@ -16,41 +16,67 @@ func (z *Residue) Mul(x *Residue) *Residue {
 
 	if z.m != x.m {
 		if z.m.m != x.m.m {
-			panic("Incompatible moduli")
+			panic(ErrIncompatibleModuli)
 		}
 	}
 
-	// Multiplication
+	return z.mul(z, x)
+}
 
-	var c, t0, t1, q0, q1, q2, q3, q4, q5, q6, q7 uint64
+// Product computes the product of two residues x and y, and stores it in z.
+// Any of x, y and z may be the same residue.
+func (z *Residue) Product(x, y *Residue) *Residue {
+	if x == y {
+		return z.SquareOf(x)
+	}
 
-	q2, q1 = Mul64(z.r[0], x.r[1])
-	q4, q3 = Mul64(z.r[0], x.r[3])
+	if x.m != y.m {
+		if x.m.m != y.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	return z.mul(x, y)
+}
 
-	t1, q0 = Mul64(z.r[0], x.r[0]); q1, c = Add64(q1, t1, 0)
-	t1, t0 = Mul64(z.r[0], x.r[2]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c); q4, _ = Add64(q4, 0, c)
+// mul computes the product of x and y, and stores it in z, without checking the moduli.
+func (z *Residue) mul(x, y *Residue) *Residue {
+	var p [8]uint64
+
+	mul512(&p, &x.r, &y.r)
+
+	z.m = x.m
+
+	return z.reduce8(p)
+}
+
+// mul512Generic computes the 512-bit product of two 256-bit values.
+func mul512Generic(z *[8]uint64, x, y *[4]uint64) {
+	var c, t0, t1, q0, q1, q2, q3, q4, q5, q6, q7 uint64
 
-	t1, t0 = Mul64(z.r[1], x.r[1]); q2, c = Add64(q2, t0, 0); q3, c = Add64(q3, t1, c)
-	q5, t0 = Mul64(z.r[1], x.r[3]); q4, c = Add64(q4, t0, c); q5, _ = Add64(q5,  0, c)
+	q2, q1 = Mul64(x[0], y[1])
+	q4, q3 = Mul64(x[0], y[3])
 
-	t1, t0 = Mul64(z.r[1], x.r[0]); q1, c = Add64(q1, t0, 0); q2, c = Add64(q2, t1, c)
-	t1, t0 = Mul64(z.r[1], x.r[2]); q3, c = Add64(q3, t0, c); q4, c = Add64(q4, t1, c); q5, _ = Add64(q5, 0, c)
+	t1, q0 = Mul64(x[0], y[0]); q1, c = Add64(q1, t1, 0)
+	t1, t0 = Mul64(x[0], y[2]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c); q4, _ = Add64(q4, 0, c)
 
-	t1, t0 = Mul64(z.r[2], x.r[1]); q3, c = Add64(q3, t0, 0); q4, c = Add64(q4, t1, c)
-	q6, t0 = Mul64(z.r[2], x.r[3]); q5, c = Add64(q5, t0, c); q6, _ = Add64(q6,  0, c)
+	t1, t0 = Mul64(x[1], y[1]); q2, c = Add64(q2, t0, 0); q3, c = Add64(q3, t1, c)
+	q5, t0 = Mul64(x[1], y[3]); q4, c = Add64(q4, t0, c); q5, _ = Add64(q5,  0, c)
 
-	t1, t0 = Mul64(z.r[2], x.r[0]); q2, c = Add64(q2, t0, 0); q3, c = Add64(q3, t1, c)
-	t1, t0 = Mul64(z.r[2], x.r[2]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, t1, c); q6, _ = Add64(q6, 0, c)
+	t1, t0 = Mul64(x[1], y[0]); q1, c = Add64(q1, t0, 0); q2, c = Add64(q2, t1, c)
+	t1, t0 = Mul64(x[1], y[2]); q3, c = Add64(q3, t0, c); q4, c = Add64(q4, t1, c); q5, _ = Add64(q5, 0, c)
 
-	t1, t0 = Mul64(z.r[3], x.r[1]); q4, c = Add64(q4, t0, 0); q5, c = Add64(q5, t1, c)
-	q7, t0 = Mul64(z.r[3], x.r[3]); q6, c = Add64(q6, t0, c); q7, _ = Add64(q7,  0, c)
+	t1, t0 = Mul64(x[2], y[1]); q3, c = Add64(q3, t0, 0); q4, c = Add64(q4, t1, c)
+	q6, t0 = Mul64(x[2], y[3]); q5, c = Add64(q5, t0, c); q6, _ = Add64(q6,  0, c)
 
-	t1, t0 = Mul64(z.r[3], x.r[0]); q3, c = Add64(q3, t0, 0); q4, c = Add64(q4, t1, c)
-	t1, t0 = Mul64(z.r[3], x.r[2]); q5, c = Add64(q5, t0, c); q6, c = Add64(q6, t1, c); q7, _ = Add64(q7, 0, c)
+	t1, t0 = Mul64(x[2], y[0]); q2, c = Add64(q2, t0, 0); q3, c = Add64(q3, t1, c)
+	t1, t0 = Mul64(x[2], y[2]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, t1, c); q6, _ = Add64(q6, 0, c)
 
-	// Reduction
+	t1, t0 = Mul64(x[3], y[1]); q4, c = Add64(q4, t0, 0); q5, c = Add64(q5, t1, c)
+	q7, t0 = Mul64(x[3], y[3]); q6, c = Add64(q6, t0, c); q7, _ = Add64(q7,  0, c)
 
-	z.reduce8([8]uint64{ q0, q1, q2, q3, q4, q5, q6, q7 })
+	t1, t0 = Mul64(x[3], y[0]); q3, c = Add64(q3, t0, 0); q4, c = Add64(q4, t1, c)
+	t1, t0 = Mul64(x[3], y[2]); q5, c = Add64(q5, t0, c); q6, c = Add64(q6, t1, c); q7, _ = Add64(q7, 0, c)
 
-	return z
+	*z = [8]uint64{ q0, q1, q2, q3, q4, q5, q6, q7 }
 }
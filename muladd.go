@@ -0,0 +1,101 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// MulAdd computes z + x*y, and stores it in z.
+// The 512-bit product is added to z before a single reduction, so it costs about the same as Mul.
+// Any of x, y and z may be the same residue.
+func (z *Residue) MulAdd(x, y *Residue) *Residue {
+	var (
+		c uint64
+		p [8]uint64
+	)
+
+	if z.m != x.m || z.m != y.m {
+		if z.m.m != x.m.m || z.m.m != y.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	if x == y {
+		sqr512(&p, &x.r)
+	} else {
+		mul512(&p, &x.r, &y.r)
+	}
+
+	// x*y + z <= (2^256-1)^2 + 2^256-1 < 2^512
+
+	p[0], c = Add64(p[0], z.r[0], 0)
+	p[1], c = Add64(p[1], z.r[1], c)
+	p[2], c = Add64(p[2], z.r[2], c)
+	p[3], c = Add64(p[3], z.r[3], c)
+	p[4], c = Add64(p[4], 0, c)
+	p[5], c = Add64(p[5], 0, c)
+	p[6], c = Add64(p[6], 0, c)
+	p[7], _ = Add64(p[7], 0, c)
+
+	return z.reduce8(p)
+}
+
+// DotProduct computes the sum of a[i]*b[i], and stores it in dst.
+// The 512-bit products are summed in a 576-bit accumulator, which is reduced once at the end,
+// so the cost is close to that of the 512-bit multiplications alone.
+//
+// The slices a and b must have the same length, and all residues must have the same modulus.
+// The residue dst may be one of the residues in a or b. With empty slices, dst is set to 0 with its current modulus.
+func DotProduct(dst *Residue, a, b []Residue) *Residue {
+	var (
+		c   uint64
+		p   [8]uint64
+		acc [9]uint64
+	)
+
+	if len(a) != len(b) {
+		panic("Length mismatch")
+	}
+
+	m := dst.m
+
+	if len(a) > 0 {
+		m = a[0].m
+	}
+
+	if m == nil {
+		panic(ErrUninitialized)
+	}
+
+	for i := range a {
+		if a[i].m != m || b[i].m != m {
+			if a[i].m.m != m.m || b[i].m.m != m.m {
+				panic(ErrIncompatibleModuli)
+			}
+		}
+	}
+
+	for i := range a {
+		mul512(&p, &a[i].r, &b[i].r)
+
+		acc[0], c = Add64(acc[0], p[0], 0)
+		acc[1], c = Add64(acc[1], p[1], c)
+		acc[2], c = Add64(acc[2], p[2], c)
+		acc[3], c = Add64(acc[3], p[3], c)
+		acc[4], c = Add64(acc[4], p[4], c)
+		acc[5], c = Add64(acc[5], p[5], c)
+		acc[6], c = Add64(acc[6], p[6], c)
+		acc[7], c = Add64(acc[7], p[7], c)
+		acc[8] += c
+	}
+
+	// acc = h*2^64 + l, with h reduced first
+
+	dst.m = m
+	dst.reduce8([8]uint64{ acc[1], acc[2], acc[3], acc[4], acc[5], acc[6], acc[7], acc[8] })
+
+	return dst.reduce8([8]uint64{ acc[0], dst.r[0], dst.r[1], dst.r[2], dst.r[3], 0, 0, 0 })
+}
@@ -0,0 +1,279 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+// strausWindow is the sliding window size for the variable bases in a multi-exponentiation.
+// Each base needs a table of 2^(strausWindow-1) odd powers.
+const strausWindow = 4
+
+// MultiExp computes the product of bases[i]^exps[i], and stores it in dst.
+// All bases share one chain of at most 255 squarings. Small batches use interleaved sliding windows (Straus),
+// costing about 59 multiplications per base, and large batches use buckets (Pippenger) when that needs fewer.
+//
+// The slices bases and exps must have the same length, and all bases must have the same modulus.
+// The residue dst may be one of the bases. With no bases, dst is set to 1 with its current modulus.
+//
+// Unlike the rest of the arithmetic, each call allocates its scratch space: with Straus
+// 8 residues and 256 bytes of window digits per base, and with Pippenger 2^c residues and flags for windows of c bits.
+func MultiExp(dst *Residue, bases []Residue, exps [][4]uint64) *Residue {
+	return MultiExpPrecomp(dst, nil, nil, bases, exps)
+}
+
+// MultiExpPrecomp is like MultiExp, but also multiplies by fixed[i]^fixedExps[i] for bases with precomputed tables.
+// The fixed bases share the squaring chain, so they only cost the multiplications of ExpPrecomp.
+//
+// The slices fixed and fixedExps must have the same length, and all bases must have the same modulus.
+// Only the variable bases need scratch space, so with fixed bases alone it does not allocate.
+func MultiExpPrecomp(dst *Residue, fixed []*ExpBase, fixedExps [][4]uint64, bases []Residue, exps [][4]uint64) *Residue {
+	var t Residue
+
+	if len(bases) != len(exps) || len(fixed) != len(fixedExps) {
+		panic("Length mismatch")
+	}
+
+	m := dst.m
+
+	switch {
+	case len(bases) > 0:
+		m = bases[0].m
+	case len(fixed) > 0:
+		m = fixed[0].table(0)[0].m
+	}
+
+	if m == nil {
+		panic(ErrUninitialized)
+	}
+
+	for i := range bases {
+		if bases[i].m != m {
+			if bases[i].m.m != m.m {
+				panic(ErrIncompatibleModuli)
+			}
+		}
+	}
+
+	for i := range fixed {
+		if f := fixed[i].table(0)[0].m; f != m {
+			if f.m != m.m {
+				panic(ErrIncompatibleModuli)
+			}
+		}
+	}
+
+	if c := pippengerWindow(len(bases)); c > 0 {
+		t.pippenger(m, bases, exps, c)
+
+		return dst.straus(m, fixed, fixedExps, nil, nil).Mul(&t)
+	}
+
+	return dst.straus(m, fixed, fixedExps, bases, exps)
+}
+
+// pippengerWindow returns the window size minimizing the multiplications for n bases with Pippenger,
+// or 0 if Straus needs fewer.
+func pippengerWindow(n int) int {
+	best, cost := 0, n * (1 << (strausWindow-1) + 256 / (strausWindow+1))
+
+	for c := 2; c <= 16; c++ {
+		if k := (256 + c - 1) / c * (n + 2 << uint(c)); k < cost {
+			best, cost = c, k
+		}
+	}
+
+	return best
+}
+
+// straus computes the product of the powers of the fixed and variable bases with one squaring chain.
+// Each variable base uses a sliding window over a table of odd powers, and each fixed base
+// multiplies in column c of its comb when c squarings remain.
+func (z *Residue) straus(m *Modulus, fixed []*ExpBase, fixedExps [][4]uint64, bases []Residue, exps [][4]uint64) *Residue {
+	var r Residue
+
+	const k = 1 << (strausWindow-1) // table entries per base
+
+	n := len(bases)
+
+	t := make([]Residue, n*k)
+	d := make([]uint8, n*256)
+
+	top := -1
+
+	for i := range bases {
+		t[i*k].Copy(&bases[i])
+		r.SquareOf(&bases[i])
+
+		for j := 1; j < k; j++ {
+			t[i*k + j].Copy(&t[i*k + j-1]).Mul(&r)
+		}
+
+		if h := slidingDigits(&exps[i], strausWindow, d[i*256:(i+1)*256]); h > top {
+			top = h
+		}
+	}
+
+	for _, x := range fixed {
+		if x.spacing-1 > top {
+			top = x.spacing-1
+		}
+	}
+
+	// z is only set by the first multiplication, so leading squarings and products with 1 are skipped
+
+	started := false
+
+	for i := top; i >= 0; i-- {
+		if started {
+			z.Square()
+		}
+
+		for j := 0; j < n; j++ {
+			if v := d[j*256 + i]; v != 0 {
+				started = z.mulOrCopy(&t[j*k + int(v >> 1)], started)
+			}
+		}
+
+		for j, x := range fixed {
+			if i >= x.spacing {
+				continue
+			}
+
+			for l := 0; l < x.tables; l++ {
+				if v := x.index(&fixedExps[j], l, i); v != 0 {
+					started = z.mulOrCopy(&x.table(l)[v], started)
+				}
+			}
+		}
+	}
+
+	if !started {
+		z.SetOne(m)
+	}
+
+	return z
+}
+
+// pippenger computes the product of bases[i]^exps[i] with windows of c bits.
+// For each window the bases are multiplied into buckets by their digit,
+// and the product of bucket[v]^v is formed with running products.
+func (z *Residue) pippenger(m *Modulus, bases []Residue, exps [][4]uint64, c int) *Residue {
+	var s, t Residue
+
+	b := make([]Residue, 1 << uint(c))
+	set := make([]bool, 1 << uint(c))
+
+	started := false
+
+	for w := (256 + c - 1) / c - 1; w >= 0; w-- {
+		if started {
+			for i := 0; i < c; i++ {
+				z.Square()
+			}
+		}
+
+		for v := range set {
+			set[v] = false
+		}
+
+		for i := range bases {
+			if v := window(&exps[i], w*c, c); v != 0 {
+				set[v] = b[v].mulOrCopy(&bases[i], set[v])
+			}
+		}
+
+		// s = bucket[2^c-1] * ... * bucket[v], t = s_(2^c-1) * ... * s_1
+
+		sSet, tSet := false, false
+
+		for v := len(b)-1; v > 0; v-- {
+			if set[v] {
+				sSet = s.mulOrCopy(&b[v], sSet)
+			}
+
+			if sSet {
+				tSet = t.mulOrCopy(&s, tSet)
+			}
+		}
+
+		if tSet {
+			started = z.mulOrCopy(&t, started)
+		}
+	}
+
+	if !started {
+		z.SetOne(m)
+	}
+
+	return z
+}
+
+// mulOrCopy multiplies z by x if set, and otherwise copies x to z. It returns true.
+func (z *Residue) mulOrCopy(x *Residue, set bool) bool {
+	if set {
+		z.Mul(x)
+	} else {
+		z.Copy(x)
+	}
+
+	return true
+}
+
+// slidingDigits splits a 256-bit exponent into left-to-right sliding windows of at most w bits.
+// The odd value of each window is stored in d at the position of its lowest bit, and other entries are set to 0.
+// Returns the position of the highest set bit, or -1 for a zero exponent.
+func slidingDigits(y *[4]uint64, w int, d []uint8) int {
+	bit := func(i int) uint8 {
+		return uint8(y[i / 64] >> uint(i % 64) & 1)
+	}
+
+	for i := range d {
+		d[i] = 0
+	}
+
+	top := -1
+
+	for i := 255; i >= 0; {
+		if bit(i) == 0 {
+			i--
+			continue
+		}
+
+		if top < 0 {
+			top = i
+		}
+
+		j := i - w + 1
+		if j < 0 {
+			j = 0
+		}
+
+		for bit(j) == 0 {
+			j++
+		}
+
+		v := uint8(0)
+
+		for l := i; l >= j; l-- {
+			v = v << 1 | bit(l)
+		}
+
+		d[j] = v
+		i = j - 1
+	}
+
+	return top
+}
+
+// window returns the c bits of a 256-bit exponent starting at bit p, with bits above 255 read as 0.
+func window(y *[4]uint64, p, c int) int {
+	i, s := p / 64, uint(p % 64)
+
+	v := y[i] >> s
+
+	if s + uint(c) > 64 && i < 3 {
+		v |= y[i+1] << (64 - s)
+	}
+
+	return int(v & (1 << uint(c) - 1))
+}
@@ -0,0 +1,79 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// MulUint64 computes the product of a residue and a 64-bit integer.
+// It needs a 4x1 word multiplication and a short Barrett reduction of the 320-bit product.
+func (z *Residue) MulUint64(k uint64) *Residue {
+	var h, l, c, b uint64
+
+	// t = z * k, 320 bits
+
+	h,  t0 := Mul64(z.r[0], k)
+	l,  t1 := Mul64(z.r[1], k); t1, c = Add64(t1, h, 0); h = l
+	l,  t2 := Mul64(z.r[2], k); t2, c = Add64(t2, h, c); h = l
+	t4, t3 := Mul64(z.r[3], k); t3, c = Add64(t3, h, c); t4 += c
+
+	if constantTime {
+		return z.reduce8([8]uint64{ t0, t1, t2, t3, t4, 0, 0, 0 })
+	}
+
+	if t4 == 0 {
+		z.r[3], z.r[2], z.r[1], z.r[0] = t3, t2, t1, t0
+		return z
+	}
+
+	mu := &z.m.mu
+	m := &z.m.m
+
+	// q = (t/2^192 * mu/2^192) / 2^128 <= t/m, with t/m - q < 4
+
+	a1, _  := Mul64(t3, mu[3])
+	b1, b0 := Mul64(t3, mu[4])
+	c1, c0 := Mul64(t4, mu[3])
+	q1, q0 := Mul64(t4, mu[4])
+
+	w1, c := Add64(a1, b0, 0)
+	_,  d := Add64(w1, c0, 0)
+
+	q0, c = Add64(q0, b1, c); q1 += c
+	q0, c = Add64(q0, c1, d); q1 += c
+
+	// r = t - q*m mod 2^320, r < 4m
+
+	h,  p0 := Mul64(q0, m[0])
+	l,  p1 := Mul64(q0, m[1]); p1, c = Add64(p1, h, 0); h = l
+	l,  p2 := Mul64(q0, m[2]); p2, c = Add64(p2, h, c); h = l
+	p4, p3 := Mul64(q0, m[3]); p3, c = Add64(p3, h, c); p4 += c
+
+	h,  l  = Mul64(q1, m[0]); p1, c = Add64(p1, l, 0); p2, c = Add64(p2, h, c); p3, c = Add64(p3, 0, c); p4 += c
+	h,  l  = Mul64(q1, m[1]); p2, c = Add64(p2, l, 0); p3, c = Add64(p3, h, c); p4 += c
+	h,  l  = Mul64(q1, m[2]); p3, c = Add64(p3, l, 0); p4 += h + c
+	p4 += q1 * m[3]
+
+	t0, b = Sub64(t0, p0, 0)
+	t1, b = Sub64(t1, p1, b)
+	t2, b = Sub64(t2, p2, b)
+	t3, b = Sub64(t3, p3, b)
+	t4, _ = Sub64(t4, p4, b)
+
+	// Subtract m until r fits in 256 bits
+
+	for t4 != 0 {
+		t0, b = Sub64(t0, m[0], 0)
+		t1, b = Sub64(t1, m[1], b)
+		t2, b = Sub64(t2, m[2], b)
+		t3, b = Sub64(t3, m[3], b)
+		t4 -= b
+	}
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = t3, t2, t1, t0
+
+	return z
+}
@@ -10,6 +10,10 @@ import (
 
 // Neg computes the negation (additive inverse) of a residue.
 func (z *Residue) Neg() *Residue {
+	if constantTime {
+		return z.NegCT()
+	}
+
 	t0, b := Sub64(z.m.mmu0[0], z.r[0], 0)
 	t1, b := Sub64(z.m.mmu0[1], z.r[1], b)
 	t2, b := Sub64(z.m.mmu0[2], z.r[2], b)
@@ -13,6 +13,10 @@ import (
 // and stores it back in z
 func (z *Residue) reduce4() *Residue {
 
+	if constantTime {
+		return z.reduce4CT()
+	}
+
 	// NB: Most variable names in the comments match the pseudocode for
 	// 	Barrett reduction in the Handbook of Applied Cryptography.
 
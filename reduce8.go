@@ -9,7 +9,26 @@ import (
 )
 
 // reduce8 computes a 256-bit residue of x modulo z.m and stores it in z
+// Moduli of special form use faster reductions than Barrett.
 func (z *Residue) reduce8(x [8]uint64) *Residue {
+	if !constantTime {
+		switch z.m.red {
+		case reductionPseudoMersenne:
+			return z.reducePseudoMersenne(x)
+		case reductionP224:
+			return z.reduceP224(x)
+		}
+	}
+
+	return z.reduce8Barrett(x)
+}
+
+// reduce8Generic computes a 256-bit residue of x modulo z.m and stores it in z
+func (z *Residue) reduce8Generic(x [8]uint64) *Residue {
+
+	if constantTime {
+		return z.reduce8CT(x)
+	}
 
 	// NB: Most variable names in the comments match the pseudocode for
 	// 	Barrett reduction in the Handbook of Applied Cryptography.
@@ -0,0 +1,55 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"sync"
+)
+
+// The registry maps modulus values to modulus objects, allowing decoded residues
+// to share modulus objects instead of recomputing the derived values.
+var registry struct {
+	sync.RWMutex
+	m map[[4]uint64]*Modulus
+}
+
+// RegisterModulus makes a modulus object available to LookupModulus and to decoding of residues.
+// A later registration of the same modulus value replaces the earlier one.
+func RegisterModulus(m *Modulus) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.m == nil {
+		registry.m = make(map[[4]uint64]*Modulus)
+	}
+
+	registry.m[m.m] = m
+}
+
+// LookupModulus returns the registered modulus object with the given value, or nil if there is none.
+func LookupModulus(m [4]uint64) *Modulus {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.m[m]
+}
+
+// bindModulus returns the modulus object to use when decoding a residue modulo m into z.
+// A modulus already set in z must match m. Otherwise a registered modulus object is used if
+// available, and a new one is created if not.
+func (z *Residue) bindModulus(m [4]uint64) (*Modulus, error) {
+	if z.m != nil {
+		if z.m.m != m {
+			return nil, ErrIncompatibleModuli
+		}
+		return z.m, nil
+	}
+
+	if x := LookupModulus(m); x != nil {
+		return x, nil
+	}
+
+	return NewModulusFromUint64(m)
+}
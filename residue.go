@@ -16,7 +16,7 @@ type Residue struct {
 func (z *Residue) FromUint64(m *Modulus, x [4]uint64) *Residue {
 
 	if m.m[3] == 0 {
-		panic("Modulus < 2^192")
+		panic(ErrModulusTooSmall)
 	}
 
 	z.m = m
@@ -24,6 +24,43 @@ func (z *Residue) FromUint64(m *Modulus, x [4]uint64) *Residue {
 	return z
 }
 
+// SetZero sets the residue value to 0.
+func (z *Residue) SetZero(m *Modulus) *Residue {
+	return z.FromUint64(m, [4]uint64{ 0, 0, 0, 0 })
+}
+
+// SetOne sets the residue value to 1.
+func (z *Residue) SetOne(m *Modulus) *Residue {
+	return z.FromUint64(m, [4]uint64{ 1, 0, 0, 0 })
+}
+
+// SetUint64 sets the residue value from a uint64.
+func (z *Residue) SetUint64(m *Modulus, x uint64) *Residue {
+	return z.FromUint64(m, [4]uint64{ x, 0, 0, 0 })
+}
+
+// SetInt64 sets the residue value from an int64.
+// Negative values are mapped to m-|x|.
+func (z *Residue) SetInt64(m *Modulus, x int64) *Residue {
+	if x < 0 {
+		return z.SetUint64(m, uint64(-x)).Neg()
+	}
+
+	return z.SetUint64(m, uint64(x))
+}
+
+// IsZero returns true if the residue is 0.
+func (z *Residue) IsZero() bool {
+	z.reduce4() // Reduce to canonical residue
+	return (z.r[3] | z.r[2] | z.r[1] | z.r[0]) == 0
+}
+
+// IsOne returns true if the residue is 1.
+func (z *Residue) IsOne() bool {
+	z.reduce4() // Reduce to canonical residue
+	return (z.r[3] | z.r[2] | z.r[1] | (z.r[0] ^ 1)) == 0
+}
+
 // ToUint64 returns an array with the canonical representative of the residue class.
 func (z *Residue) ToUint64() [4]uint64 {
 	z.reduce4() // Reduce to canonical residue
@@ -38,6 +75,12 @@ func (z *Residue) Copy(x *Residue) *Residue {
 	return z
 }
 
+// append256 computes z*2^256 + x, reduces it modulo z.m and stores it in z.
+// Repeated calls convert a number of any length, one 256-bit chunk at a time.
+func (z *Residue) append256(x [4]uint64) *Residue {
+	return z.reduce8([8]uint64{ x[0], x[1], x[2], x[3], z.r[0], z.r[1], z.r[2], z.r[3] })
+}
+
 // shiftleft256 shifts the 256-bit value in a little-endian array left by 0-63 bits.
 func shiftleft256(x [4]uint64, s uint) (z [4]uint64) {
 	l := s % 64	// left shift
@@ -50,3 +93,19 @@ func shiftleft256(x [4]uint64, s uint) (z [4]uint64) {
 
 	return z
 }
+
+// shiftright256 shifts the 256-bit value in a little-endian array right by 0-255 bits.
+func shiftright256(x [4]uint64, s uint) (z [4]uint64) {
+	w := s / 64	// whole words
+	r := s % 64	// right shift
+	l := 64 - r	// left shift
+
+	for i := uint(0); i+w < 4; i++ {
+		z[i] = x[i+w] >> r
+		if i+w+1 < 4 {
+			z[i] |= x[i+w+1] << l
+		}
+	}
+
+	return z
+}
@@ -0,0 +1,97 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+// The methods in this file are constant-time building blocks for
+// e.g. Montgomery ladders and fixed-window exponentiation.
+// A condition is true when non-zero, and the residue values are
+// selected with masks instead of branches.
+
+// mask returns all ones if cond is non-zero, and zero otherwise.
+func mask(cond uint64) uint64 {
+	return -((cond | -cond) >> 63)
+}
+
+// Select sets z to a if cond is non-zero, and to b otherwise, in constant time.
+func (z *Residue) Select(cond uint64, a, b *Residue) *Residue {
+	if a.m != b.m {
+		if a.m.m != b.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	s := mask(cond)
+
+	z.m = a.m
+	z.r[0] = b.r[0] ^ (s & (a.r[0] ^ b.r[0]))
+	z.r[1] = b.r[1] ^ (s & (a.r[1] ^ b.r[1]))
+	z.r[2] = b.r[2] ^ (s & (a.r[2] ^ b.r[2]))
+	z.r[3] = b.r[3] ^ (s & (a.r[3] ^ b.r[3]))
+
+	return z
+}
+
+// CondSwap swaps the values of a and b if cond is non-zero, in constant time.
+func CondSwap(a, b *Residue, cond uint64) {
+	if a.m != b.m {
+		if a.m.m != b.m.m {
+			panic(ErrIncompatibleModuli)
+		}
+	}
+
+	s := mask(cond)
+
+	t0 := s & (a.r[0] ^ b.r[0])
+	t1 := s & (a.r[1] ^ b.r[1])
+	t2 := s & (a.r[2] ^ b.r[2])
+	t3 := s & (a.r[3] ^ b.r[3])
+
+	a.r[0] ^= t0; b.r[0] ^= t0
+	a.r[1] ^= t1; b.r[1] ^= t1
+	a.r[2] ^= t2; b.r[2] ^= t2
+	a.r[3] ^= t3; b.r[3] ^= t3
+}
+
+// CondNeg negates z if cond is non-zero, in constant time.
+func (z *Residue) CondNeg(cond uint64) *Residue {
+	var t Residue
+
+	t.Copy(z).NegCT()
+
+	return z.Select(cond, &t, z)
+}
+
+// LookupTable sets z to table[idx] in constant time.
+// All entries are read, so the memory access pattern does not depend on idx.
+// The entries must have the same modulus.
+func (z *Residue) LookupTable(table []Residue, idx int) *Residue {
+	if idx < 0 || idx >= len(table) {
+		panic("Index out of range")
+	}
+
+	var r0, r1, r2, r3 uint64
+
+	m := table[0].m
+
+	for i := range table {
+		if table[i].m != m {
+			if table[i].m.m != m.m {
+				panic(ErrIncompatibleModuli)
+			}
+		}
+
+		s := ^mask(uint64(i ^ idx))
+
+		r0 |= s & table[i].r[0]
+		r1 |= s & table[i].r[1]
+		r2 |= s & table[i].r[2]
+		r3 |= s & table[i].r[3]
+	}
+
+	z.m = m
+	z.r[3], z.r[2], z.r[1], z.r[0] = r3, r2, r1, r0
+
+	return z
+}
@@ -0,0 +1,156 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// reduction identifies the method used by reduce8 for a modulus.
+type reduction int
+
+const (
+	reductionBarrett        reduction = iota // any modulus
+	reductionPseudoMersenne                  // 2^256 mod m < 2^64, e.g. secp256k1 and 2^255-19
+	reductionP224                            // NIST P-224 prime 2^224 - 2^96 + 1
+)
+
+var p224 = [4]uint64{ 0x0000000000000001, 0xffffffff00000000, 0xffffffffffffffff, 0x00000000ffffffff }
+
+// initReduction selects the reduction method for a modulus.
+// It does not depend on the Barrett constants, which may not have been validated.
+// The NIST P-256 prime is deliberately left on Barrett, as its special reduction was measured to be slower.
+func (z *Modulus) initReduction() {
+	var c uint64
+
+	m := &z.m
+
+	// k = 2^256 mod m, by doubling 2^(n-1) < m, with n the bit length of m
+
+	n := 256 - LeadingZeros64(m[3])
+
+	k0, k1, k2, k3 := uint64(0), uint64(0), uint64(0), uint64(1) << uint(n - 193)
+
+	if m[2] | m[1] | m[0] == 0 && m[3] == k3 {
+		k3 = 0
+	}
+
+	for i := n; i <= 256; i++ {
+		k0, c = Add64(k0, k0, 0)
+		k1, c = Add64(k1, k1, c)
+		k2, c = Add64(k2, k2, c)
+		k3, c = Add64(k3, k3, c)
+
+		u0, b := Sub64(k0, m[0], 0)
+		u1, b := Sub64(k1, m[1], b)
+		u2, b := Sub64(k2, m[2], b)
+		u3, b := Sub64(k3, m[3], b)
+
+		if c != 0 || b == 0 {
+			k3, k2, k1, k0 = u3, u2, u1, u0
+		}
+	}
+
+	z.k = [4]uint64{ k0, k1, k2, k3 }
+
+	switch {
+	case z.m == p224:
+		z.red = reductionP224
+	case k3 | k2 | k1 == 0:
+		z.red = reductionPseudoMersenne
+	default:
+		z.red = reductionBarrett
+	}
+}
+
+// reducePseudoMersenne computes a 256-bit residue of x modulo z.m and stores it in z.
+// It uses x = h*2^256 + l = h*k + l (mod m), with k = 2^256 mod m < 2^64.
+func (z *Residue) reducePseudoMersenne(x [8]uint64) *Residue {
+	var c, h, l uint64
+
+	k := z.m.k[0]
+
+	// t = l + h*k, 320 bits
+
+	h, l = Mul64(x[4], k); t0, c := Add64(x[0], l, 0); t1 := h
+	h, l = Mul64(x[5], k); t1, c  = Add64(t1, l, c);   t2 := h
+	h, l = Mul64(x[6], k); t2, c  = Add64(t2, l, c);   t3 := h
+	h, l = Mul64(x[7], k); t3, c  = Add64(t3, l, c);   t4 := h + c
+
+	t1, c = Add64(t1, x[1], 0)
+	t2, c = Add64(t2, x[2], c)
+	t3, c = Add64(t3, x[3], c)
+	t4 += c
+
+	// t = t mod 2^256 + t4*k, 256 bits plus a carry
+
+	h, l = Mul64(t4, k)
+
+	t0, c = Add64(t0, l, 0)
+	t1, c = Add64(t1, h, c)
+	t2, c = Add64(t2, 0, c)
+	t3, c = Add64(t3, 0, c)
+
+	// On carry t < 2^128, and adding k cannot carry again
+
+	t0, c = Add64(t0, k & -c, 0)
+	t1, c = Add64(t1, 0, c)
+	t2, c = Add64(t2, 0, c)
+	t3, _ = Add64(t3, 0, c)
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = t3, t2, t1, t0
+
+	return z
+}
+
+// reduceP224 computes a 256-bit residue of x modulo the P-224 prime and stores it in z.
+// It uses x = h*2^256 + l = h*(2^128 - 2^32) + l (mod m), folding until the result fits in 256 bits.
+func (z *Residue) reduceP224(x [8]uint64) *Residue {
+	var c, b uint64
+
+	h0, h1, h2, h3 := x[4], x[5], x[6], x[7]
+
+	// t = l + h*2^128 - h*2^32, 384 bits
+
+	a2, c := Add64(x[2], h0, 0)
+	a3, c := Add64(x[3], h1, c)
+	a4, c := Add64(h2, 0, c)
+	a5    := h3 + c
+
+	t0, b := Sub64(x[0], h0 << 32, 0)
+	t1, b := Sub64(x[1], h1 << 32 | h0 >> 32, b)
+	t2, b := Sub64(a2,   h2 << 32 | h1 >> 32, b)
+	t3, b := Sub64(a3,   h3 << 32 | h2 >> 32, b)
+	t4, b := Sub64(a4,   h3 >> 32, b)
+	t5    := a5 - b
+
+	// t = t mod 2^256 + t' * 2^128 - t' * 2^32, with t' = t/2^256 < 2^128, 257 bits
+
+	t2, c = Add64(t2, t4, 0)
+	t3, c = Add64(t3, t5, c)
+	a4    = c
+
+	t0, b = Sub64(t0, t4 << 32, 0)
+	t1, b = Sub64(t1, t5 << 32 | t4 >> 32, b)
+	t2, b = Sub64(t2, t5 >> 32, b)
+	t3, b = Sub64(t3, 0, b)
+	a4   -= b
+
+	// Fold the last bit, and once more if that carries
+
+	k := &z.m.k
+
+	for a4 != 0 {
+		t0, c = Add64(t0, k[0], 0)
+		t1, c = Add64(t1, k[1], c)
+		t2, c = Add64(t2, k[2], c)
+		t3, c = Add64(t3, k[3], c)
+		a4 = c
+	}
+
+	z.r[3], z.r[2], z.r[1], z.r[0] = t3, t2, t1, t0
+
+	return z
+}
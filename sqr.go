@@ -10,18 +10,32 @@ import (
 
 // Square computes the square of a residue.
 func (z *Residue) Square() *Residue {
-	// Squaring
+	return z.SquareOf(z)
+}
+
+// SquareOf computes the square of a residue x, and stores it in z.
+func (z *Residue) SquareOf(x *Residue) *Residue {
+	var p [8]uint64
+
+	sqr512(&p, &x.r)
+
+	z.m = x.m
 
+	return z.reduce8(p)
+}
+
+// sqr512Generic computes the 512-bit square of a 256-bit value.
+func sqr512Generic(z *[8]uint64, x *[4]uint64) {
 	var c, t0, t1, q0, q1, q2, q3, q4, q5, q6, q7 uint64
 
-	q4, q3 = Mul64(z.r[0], z.r[3])
+	q4, q3 = Mul64(x[0], x[3])
 
-	t1, q2 = Mul64(z.r[0], z.r[2]); q3, c = Add64(q3, t1, 0)
-	q5, t0 = Mul64(z.r[1], z.r[3]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, 0, c)
+	t1, q2 = Mul64(x[0], x[2]); q3, c = Add64(q3, t1, 0)
+	q5, t0 = Mul64(x[1], x[3]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, 0, c)
 
-	t1, q1 = Mul64(z.r[0], z.r[1]); q2, c = Add64(q2, t1, 0)
-	t1, t0 = Mul64(z.r[1], z.r[2]); q3, c = Add64(q3, t0, c); q4, c = Add64(q4, t1, c)
-	q6, t0 = Mul64(z.r[2], z.r[3]); q5, c = Add64(q5, t0, c); q6, c = Add64(q6, 0, c)
+	t1, q1 = Mul64(x[0], x[1]); q2, c = Add64(q2, t1, 0)
+	t1, t0 = Mul64(x[1], x[2]); q3, c = Add64(q3, t0, c); q4, c = Add64(q4, t1, c)
+	q6, t0 = Mul64(x[2], x[3]); q5, c = Add64(q5, t0, c); q6, c = Add64(q6, 0, c)
 
 	q1, c = Add64(q1, q1, 0)
 	q2, c = Add64(q2, q2, c)
@@ -31,14 +45,10 @@ func (z *Residue) Square() *Residue {
 	q6, c = Add64(q6, q6, c)
 	q7, _ = Add64( 0,  0, c)
 
-	t1, q0 = Mul64(z.r[0], z.r[0]); q1, c = Add64(q1, t1, 0)
-	t1, t0 = Mul64(z.r[1], z.r[1]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c)
-	t1, t0 = Mul64(z.r[2], z.r[2]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, t1, c)
-	t1, t0 = Mul64(z.r[3], z.r[3]); q6, c = Add64(q6, t0, c); q7, _ = Add64(q7, t1, c)
-
-	// Reduction
-
-	z.reduce8([8]uint64{ q0, q1, q2, q3, q4, q5, q6, q7 })
+	t1, q0 = Mul64(x[0], x[0]); q1, c = Add64(q1, t1, 0)
+	t1, t0 = Mul64(x[1], x[1]); q2, c = Add64(q2, t0, c); q3, c = Add64(q3, t1, c)
+	t1, t0 = Mul64(x[2], x[2]); q4, c = Add64(q4, t0, c); q5, c = Add64(q5, t1, c)
+	t1, t0 = Mul64(x[3], x[3]); q6, c = Add64(q6, t0, c); q7, _ = Add64(q7, t1, c)
 
-	return z
+	*z = [8]uint64{ q0, q1, q2, q3, q4, q5, q6, q7 }
 }
@@ -0,0 +1,184 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	. "math/bits"
+)
+
+// sqrtConstants contains values used by Tonelli-Shanks square roots modulo m
+type sqrtConstants struct {
+	s  uint      // 2-adic valuation of m-1
+	q  [4]uint64 // (m-1)/2^s
+	c  [4]uint64 // n^q for a quadratic non-residue n
+	ok bool      // true if a non-residue was found
+}
+
+// initSqrt computes the Tonelli-Shanks constants of the modulus.
+func (z *Modulus) initSqrt() {
+	var n Residue
+
+	m := z.m
+
+	// m-1 = q * 2^s, q odd
+
+	m[0] &^= 1
+
+	s := uint(TrailingZeros64(m[0]))
+
+	switch {
+	case m[0] != 0:
+	case m[1] != 0: s =  64 + uint(TrailingZeros64(m[1]))
+	case m[2] != 0: s = 128 + uint(TrailingZeros64(m[2]))
+	default:        s = 192 + uint(TrailingZeros64(m[3]))
+	}
+
+	z.sqrt.s = s
+	z.sqrt.q = shiftright256(m, s)
+
+	// Search for a quadratic non-residue n
+	// For a prime modulus each attempt succeeds with probability 1/2
+
+	for i := uint64(2); i < 66; i++ {
+		n.SetUint64(z, i)
+
+		if n.Legendre() == -1 {
+			z.sqrt.c = n.Exp(z.sqrt.q).ToUint64()
+			z.sqrt.ok = true
+			return
+		}
+	}
+}
+
+// Sqrt computes a square root of a residue modulo a prime.
+// Returns true if the square root exists, otherwise the residue is set to 0 and false is returned.
+// For composite moduli a false result does not imply that no square root exists.
+//
+// The method depends on the modulus: a single exponentiation when m = 3 (mod 4),
+// Atkin's method when m = 5 (mod 8), and Tonelli-Shanks otherwise.
+// Tonelli-Shanks needs a quadratic non-residue, which is searched for and stored
+// with the modulus the first time it is needed.
+func (z *Residue) Sqrt() bool {
+	var x, r, t, u Residue
+
+	m := z.m.m
+
+	if m[0] & 1 == 0 { // Even modulus
+		z.SetZero(z.m)
+		return false
+	}
+
+	x.Copy(z)
+
+	switch {
+	case m[0] & 3 == 3:
+		// r = x^((m+1)/4)
+
+		e := increment256(shiftright256(m, 2))
+
+		r.Copy(&x).Exp(e)
+
+	case m[0] & 7 == 5:
+		// Atkin: t = (2x)^((m-5)/8), u = 2x * t^2, r = x * t * (u-1)
+
+		t.Copy(&x).Double()
+		u.Copy(&t)
+		t.Exp(shiftright256(m, 3))
+		u.Mul(r.SquareOf(&t)).Sub(r.SetOne(z.m))
+		r.Copy(&x).Mul(&t).Mul(&u)
+
+	default:
+		if !x.tonelliShanks(&r) {
+			z.SetZero(z.m)
+			return false
+		}
+	}
+
+	// Check the result, as x may be a non-residue
+
+	if t.SquareOf(&r).NotEqual(&x) {
+		z.SetZero(z.m)
+		return false
+	}
+
+	z.Copy(&r)
+
+	return true
+}
+
+// tonelliShanks computes a candidate square root r of x, and returns false if none was found.
+func (x *Residue) tonelliShanks(r *Residue) bool {
+	var b, c, t Residue
+
+	mod := x.m
+
+	mod.sqrtOnce.Do(mod.initSqrt)
+
+	if !mod.sqrt.ok {
+		return false
+	}
+
+	if x.IsZero() {
+		r.SetZero(mod)
+		return true
+	}
+
+	// r = x^((q+1)/2), t = x^q, c = n^q
+
+	e := increment256(shiftright256(mod.sqrt.q, 1))
+
+	r.Copy(x).Exp(e)
+	t.Copy(x).Exp(mod.sqrt.q)
+	c.FromUint64(mod, mod.sqrt.c)
+
+	// Invariants: r^2 = x*t, t^(2^(s-1)) = 1 for the current s
+
+	s := mod.sqrt.s
+
+	for !t.IsOne() {
+		// Find the least i, 0 < i < s, such that t^(2^i) = 1
+
+		var i uint
+
+		b.Copy(&t)
+
+		for i = 1; i < s; i++ {
+			if b.Square().IsOne() {
+				break
+			}
+		}
+
+		if i == s { // x is a non-residue
+			return false
+		}
+
+		// b = c^(2^(s-i-1))
+
+		b.Copy(&c)
+
+		for j := i+1; j < s; j++ {
+			b.Square()
+		}
+
+		s = i
+		c.SquareOf(&b)
+		t.Mul(&c)
+		r.Mul(&b)
+	}
+
+	return true
+}
+
+// increment256 adds 1 to the 256-bit value in a little-endian array.
+func increment256(x [4]uint64) (z [4]uint64) {
+	var c uint64
+
+	z[0], c = Add64(x[0], 1, 0)
+	z[1], c = Add64(x[1], 0, c)
+	z[2], c = Add64(x[2], 0, c)
+	z[3], _ = Add64(x[3], 0, c)
+
+	return z
+}
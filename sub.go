@@ -10,9 +10,13 @@ import (
 
 // Sub computes the sum of a residue and the negation of a second residue.
 func (z *Residue) Sub(x *Residue) *Residue {
+	if constantTime {
+		return z.SubCT(x)
+	}
+
 	if z.m != x.m {
 		if z.m.m != x.m.m {
-			panic("Incompatible moduli")
+			panic(ErrIncompatibleModuli)
 		}
 	}
 
@@ -46,3 +50,13 @@ func (z *Residue) Sub(x *Residue) *Residue {
 
 	return z
 }
+
+// Difference computes the difference of two residues x and y, and stores it in z.
+// Any of x, y and z may be the same residue.
+func (z *Residue) Difference(x, y *Residue) *Residue {
+	if z == y && z != x {
+		return z.Neg().Add(x)
+	}
+
+	return z.Copy(x).Sub(y)
+}
@@ -0,0 +1,104 @@
+// mod256: Arithmetic modulo 193-256 bit moduli
+// Copyright 2021-2022 Dag Arne Osvik
+// SPDX-License-Identifier: BSD-3-Clause
+
+package mod256
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// NewModulusFromString creates a new modulus object from a string in the given base.
+// The base and prefix rules are those of (*big.Int).SetString.
+func NewModulusFromString(s string, base int) (z *Modulus, err error) {
+	var b big.Int
+
+	if _, ok := b.SetString(s, base); !ok {
+		return nil, errors.New("Invalid modulus string")
+	}
+
+	return NewModulusFromBig(&b)
+}
+
+// String returns the modulus in hexadecimal, with a 0x prefix.
+func (z *Modulus) String() string {
+	if z == nil {
+		return "<nil>"
+	}
+
+	var b big.Int
+
+	return fmt.Sprintf("%#x", toBig(&b, z.m))
+}
+
+// Format implements fmt.Formatter.
+// The verbs 'v' and 's' give the same output as String, and all other verbs format the modulus like a big.Int.
+func (z *Modulus) Format(s fmt.State, verb rune) {
+	if z == nil || verb == 'v' || verb == 's' {
+		formatString(s, z.String())
+		return
+	}
+
+	var b big.Int
+
+	toBig(&b, z.m).Format(s, verb)
+}
+
+// SetString sets the residue value from a string in the given base, and returns z and a boolean indicating success.
+// The base and prefix rules are those of (*big.Int).SetString. Negative values are accepted.
+func (z *Residue) SetString(m *Modulus, s string, base int) (*Residue, bool) {
+	var b big.Int
+
+	if _, ok := b.SetString(s, base); !ok {
+		return z, false
+	}
+
+	return z.FromBig(m, &b), true
+}
+
+// String returns the canonical representative of the residue class and its modulus, both in hexadecimal.
+func (z *Residue) String() string {
+	if z == nil || z.m == nil {
+		return "<nil>"
+	}
+
+	var b big.Int
+
+	return fmt.Sprintf("%#x (mod %v)", z.ToBig(&b), z.m)
+}
+
+// Format implements fmt.Formatter.
+// The verbs 'v' and 's' give the same output as String, and all other verbs format
+// the canonical representative of the residue class like a big.Int.
+func (z *Residue) Format(s fmt.State, verb rune) {
+	if z == nil || z.m == nil || verb == 'v' || verb == 's' {
+		formatString(s, z.String())
+		return
+	}
+
+	var b big.Int
+
+	z.ToBig(&b).Format(s, verb)
+}
+
+// formatString writes str to s, padded to the requested width.
+func formatString(s fmt.State, str string) {
+	w, ok := s.Width()
+
+	if !ok || w <= len(str) {
+		io.WriteString(s, str)
+		return
+	}
+
+	pad := strings.Repeat(" ", w - len(str))
+
+	if s.Flag('-') {
+		io.WriteString(s, str + pad)
+	} else {
+		io.WriteString(s, pad + str)
+	}
+}